@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"clashroyale/internal/audit"
+	"clashroyale/internal/auth"
+	"clashroyale/internal/collection"
+	"clashroyale/internal/game"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// promoteAdmins makes the users named in the comma separated ADMINS
+// environment variable admins, so a fresh server has someone who can
+// hand out roles. Users who don't exist yet are skipped.
+func promoteAdmins() {
+	for _, name := range strings.Split(os.Getenv("ADMINS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		promoted := false
+		_, err := auth.Update(name, func(u *auth.User) error {
+			if !u.HasRole(auth.RoleAdmin) {
+				u.Role = auth.RoleAdmin
+				promoted = true
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("ADMINS: %v", err)
+			continue
+		}
+		if promoted {
+			record("system", "promote", name, gin.H{"role": auth.RoleAdmin})
+		}
+	}
+}
+
+// record writes an admin action to the audit trail. The action has
+// already happened, so a failure is logged rather than reported.
+func record(actor, action, target string, details gin.H) {
+	if err := audit.Record(actor, action, target, details); err != nil {
+		log.Printf("audit %s %s %s: %v", actor, action, target, err)
+	}
+}
+
+// recordAction audits an action taken by the request's user.
+func recordAction(c *gin.Context, action, target string, details gin.H) {
+	record(currentUser(c).Username, action, target, details)
+}
+
+// playerView is a user without their password hash.
+type playerView struct {
+	Username  string          `json:"username"`
+	Wallet    wallet.Balances `json:"wallet"`
+	Level     int             `json:"level"`
+	Cards     []string        `json:"cards"`
+	Shards    map[string]int  `json:"shards"`
+	Role      auth.Role       `json:"role"`
+	Sanctions []auth.Sanction `json:"sanctions"` // active ones only
+}
+
+func viewPlayer(u *auth.User) playerView {
+	role := u.Role
+	if role == "" {
+		role = auth.RolePlayer
+	}
+	balances := u.Wallet
+	if balances == nil {
+		balances = wallet.Balances{}
+	}
+	shards := make(map[string]int, len(u.Shards))
+	for name, n := range u.Shards {
+		shards[name] = n
+	}
+	cards := append([]string{}, u.Cards...)
+	return playerView{u.Username, balances, u.Level, cards, shards, role, u.ActiveSanctions(time.Now())}
+}
+
+func showAdmin(c *gin.Context) {
+	u := currentUser(c)
+	render(c, http.StatusOK, "admin.html", gin.H{
+		"Username": u.Username,
+		"IsAdmin":  u.HasRole(auth.RoleAdmin),
+	})
+}
+
+// searchPlayers lists players whose name contains ?q=, at most 50.
+func searchPlayers(c *gin.Context) {
+	users, err := auth.ListUsers()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	q := strings.ToLower(c.Query("q"))
+	out := []playerView{}
+	for _, u := range users {
+		if strings.Contains(strings.ToLower(u.Username), q) {
+			out = append(out, viewPlayer(u))
+		}
+		if len(out) == 50 {
+			break
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"players": out})
+}
+
+// playerUpdate is the body of a balance/level adjustment. Unset fields
+// and currencies left out of Wallet are left alone; Add is applied after
+// Wallet.
+type playerUpdate struct {
+	Wallet     wallet.Balances `json:"wallet"` // new balances
+	Add        wallet.Balances `json:"add"`    // credited, or debited if negative
+	Shards     map[string]int  `json:"shards"` // card shards to give, by troop
+	Level      *int            `json:"level"`
+	ResetUnits bool            `json:"resetUnits"` // put every troop and tower back to level 0
+}
+
+// errStaleBalances means a player's balances moved between working out
+// an admin's changes and applying them, e.g. a match paid out meanwhile.
+var errStaleBalances = errors.New("balances changed during update")
+
+// updatePlayer applies an admin's changes to a player. Target balances
+// are turned into changes from the player's current ones; if those move
+// before the changes land, they're worked out again.
+func updatePlayer(c *gin.Context) {
+	var req playerUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if req.Level != nil && *req.Level < 0 {
+		badRequest(c, "level can't be negative")
+		return
+	}
+	for _, amt := range req.Wallet {
+		if amt < 0 {
+			badRequest(c, "balances can't be negative")
+			return
+		}
+	}
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	for name, n := range req.Shards {
+		if _, ok := cat.Troop(name); !ok {
+			badRequest(c, "unknown troop "+name)
+			return
+		}
+		if n < 0 {
+			badRequest(c, "shards can't be negative")
+			return
+		}
+	}
+	admin := currentUser(c).Username
+	var before playerView
+	var u *auth.User
+	for {
+		u, err = auth.LoadUser(c.Param("username"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		// turn the target balances into changes so they go through the ledger
+		before = viewPlayer(u)
+		current := make(wallet.Balances, len(req.Wallet))
+		for cur := range req.Wallet {
+			current[cur] = u.Wallet[cur]
+		}
+		changes := wallet.Sub(req.Wallet, current)
+		for cur, amt := range req.Add {
+			changes[cur] += amt
+		}
+
+		u, _, err = auth.Transact(u.Username, changes, wallet.ReasonAdmin, admin, func(u *auth.User) error {
+			for cur, amt := range req.Wallet {
+				if u.Wallet[cur] != amt+req.Add[cur] {
+					return errStaleBalances
+				}
+			}
+			if req.Level != nil {
+				u.Level = *req.Level
+			}
+			if req.ResetUnits {
+				u.TroopLevels = make(map[string]int)
+				u.TowerLevels = make(map[string]int)
+			}
+			for name, n := range req.Shards {
+				if u.Shards == nil {
+					u.Shards = make(map[string]int)
+				}
+				u.Shards[name] += n
+			}
+			// a new level, trophies or shards can unlock cards
+			collection.Sync(cat, u)
+			return nil
+		})
+		if errors.Is(err, errStaleBalances) {
+			continue
+		}
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		break
+	}
+
+	after := viewPlayer(u)
+	recordAction(c, "update_player", u.Username, gin.H{
+		"wallet":     [2]wallet.Balances{before.Wallet, after.Wallet},
+		"level":      [2]int{before.Level, after.Level},
+		"cards":      [2][]string{before.Cards, after.Cards},
+		"shards":     req.Shards,
+		"resetUnits": req.ResetUnits,
+	})
+	c.JSON(http.StatusOK, after)
+}
+
+// imposeSanction bans, suspends or mutes a player for ?minutes= (0 or
+// missing means permanently). Only admins can sanction staff. Banned and
+// suspended players are taken out of the queue straight away; a ban also
+// ends their session on their next request.
+func imposeSanction(c *gin.Context) {
+	target, err := auth.LoadUser(c.Param("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if target.HasRole(auth.RoleModerator) && !currentUser(c).HasRole(auth.RoleAdmin) {
+		writeError(c, errForbidden)
+		return
+	}
+
+	kind := auth.SanctionKind(c.PostForm("kind"))
+	minutes, _ := strconv.Atoi(c.DefaultPostForm("minutes", "0"))
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	d := time.Duration(minutes) * time.Minute
+	u, err := auth.Impose(target.Username, kind, reason, currentUser(c).Username, d)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if kind != auth.SanctionMute {
+		game.GetLobbyManager().Leave(u.Username)
+	}
+
+	recordAction(c, string(kind), u.Username, gin.H{"reason": reason, "minutes": minutes})
+	c.JSON(http.StatusOK, viewPlayer(u))
+}
+
+// liftSanction ends a player's active sanctions of one kind.
+func liftSanction(c *gin.Context) {
+	kind := auth.SanctionKind(c.PostForm("kind"))
+	u, err := auth.Lift(c.Param("username"), kind, currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "lift_"+string(kind), u.Username, nil)
+	c.JSON(http.StatusOK, viewPlayer(u))
+}
+
+func setRole(c *gin.Context) {
+	role := auth.Role(c.PostForm("role"))
+	if !auth.ValidRole(role) {
+		badRequest(c, "unknown role")
+		return
+	}
+	var before auth.Role
+	u, err := auth.Update(c.Param("username"), func(u *auth.User) error {
+		before = viewPlayer(u).Role
+		u.Role = role
+		return nil
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "set_role", u.Username, gin.H{"from": before, "to": role})
+	c.JSON(http.StatusOK, viewPlayer(u))
+}
+
+func listGames(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"games": game.LiveGames()})
+}
+
+func forceFinish(c *gin.Context) {
+	id := c.Param("gameID")
+	if err := game.ForceFinish(id); err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "finish_game", id, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func lobbyOverview(c *gin.Context) {
+	c.JSON(http.StatusOK, game.GetLobbyManager().Overview())
+}
+
+// auditTrail returns the latest ?limit= entries, 100 by default.
+func auditTrail(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		badRequest(c, "invalid limit")
+		return
+	}
+	entries, err := audit.Recent(limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func reloadSpecs(c *gin.Context) {
+	if err := spec.Reload(); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	cat, _ := spec.Current()
+	recordAction(c, "reload_specs", "", gin.H{"version": cat.Version})
+	c.JSON(http.StatusOK, gin.H{"version": cat.Version})
+}
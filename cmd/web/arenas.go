@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+
+	"clashroyale/internal/i18n"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// arenaView is an arena as one player sees it.
+type arenaView struct {
+	spec.Arena
+	Name    string `json:"name"`
+	Reached bool   `json:"reached"`
+	Current bool   `json:"current"`
+}
+
+// arenaProgress is where a player's trophies put them.
+type arenaProgress struct {
+	Trophies int        `json:"trophies"`
+	Arena    arenaView  `json:"arena"`
+	Next     *arenaView `json:"next,omitempty"` // nil in the top arena
+	Floor    int        `json:"floor"`          // fewest trophies they can drop to
+}
+
+// arenaName names an arena, falling back to its ID for ones added to the
+// specs without a translation.
+func arenaName(t *i18n.Translator, id string) string {
+	if t.Has("arena." + id) {
+		return t.T("arena." + id)
+	}
+	return id
+}
+
+// arenaNames names every arena in cat, by ID.
+func arenaNames(t *i18n.Translator, cat *spec.Catalog) map[string]string {
+	names := make(map[string]string)
+	for _, a := range cat.Arenas() {
+		names[a.ID] = arenaName(t, a.ID)
+	}
+	return names
+}
+
+// viewArenas lists every arena for a player holding trophies.
+func viewArenas(t *i18n.Translator, cat *spec.Catalog, trophies int) []arenaView {
+	_, current := cat.ArenaFor(trophies)
+	arenas := cat.Arenas()
+	out := make([]arenaView, len(arenas))
+	for i, a := range arenas {
+		out[i] = arenaView{Arena: a, Name: arenaName(t, a.ID), Reached: i <= current, Current: i == current}
+	}
+	return out
+}
+
+// viewArenaProgress describes the arena a player holding trophies is in
+// and the next one up.
+func viewArenaProgress(t *i18n.Translator, cat *spec.Catalog, trophies int) arenaProgress {
+	arenas := viewArenas(t, cat, trophies)
+	_, i := cat.ArenaFor(trophies)
+	p := arenaProgress{Trophies: trophies, Arena: arenas[i], Floor: cat.ArenaFloor(trophies)}
+	if i+1 < len(arenas) {
+		p.Next = &arenas[i+1]
+	}
+	return p
+}
+
+// showArenas returns every arena, the cards and reward multiplier each
+// brings, and where the current player stands.
+func showArenas(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	trophies := currentUser(c).Wallet[wallet.Trophies]
+	c.JSON(http.StatusOK, gin.H{
+		"progress": viewArenaProgress(tr(c), cat, trophies),
+		"arenas":   viewArenas(tr(c), cat, trophies),
+	})
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"clashroyale/internal/collection"
+	"clashroyale/internal/spec"
+
+	"github.com/gin-gonic/gin"
+)
+
+// showCards returns the current player's collection: every troop, whether
+// they own it, and what the locked ones need.
+func showCards(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	u, unlocked, err := collection.Refresh(cat, currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cards": collection.List(cat, u), "unlocked": unlocked})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/chest"
+	"clashroyale/internal/collection"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chestView is a chest as the client sees it, with its timer worked out
+// on the server.
+type chestView struct {
+	chest.Chest
+	State         string `json:"state"`
+	SecondsLeft   int    `json:"secondsLeft"`
+	UnlockSeconds int    `json:"unlockSeconds"`
+}
+
+func viewChest(cat *spec.Catalog, c chest.Chest, now time.Time) chestView {
+	v := chestView{Chest: c, State: c.State(now)}
+	if ch, ok := cat.Chest(c.Type); ok {
+		v.UnlockSeconds = ch.UnlockSeconds
+	}
+	if v.State == chest.StateUnlocking {
+		v.SecondsLeft = int(c.UnlocksAt.Sub(now).Round(time.Second) / time.Second)
+	}
+	return v
+}
+
+// viewChests lists u's chests in slot order.
+func viewChests(cat *spec.Catalog, u *auth.User) []chestView {
+	now := chest.Clock()
+	out := make([]chestView, len(u.Chests))
+	for i, c := range u.Chests {
+		out[i] = viewChest(cat, c, now)
+	}
+	return out
+}
+
+// showChests returns the current player's chests and how many slots
+// they have.
+func showChests(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	u, err := auth.LoadUser(currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"slots": cat.ChestSlots(), "chests": viewChests(cat, u)})
+}
+
+// unlockChest starts the timer on one of the player's chests.
+func unlockChest(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	var started chest.Chest
+	_, err = auth.Update(currentUser(c).Username, func(u *auth.User) error {
+		var d time.Duration
+		if held, ok := u.Chests.Get(c.Param("id")); ok {
+			ch, _ := cat.Chest(held.Type)
+			d = ch.UnlockTime()
+		}
+		s, err := u.Chests.Start(c.Param("id"), d, chest.Clock())
+		started = s
+		return err
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewChest(cat, started, chest.Clock()))
+}
+
+// openChest opens an unlocked chest: its gold and EXP go to the wallet
+// and its shards to the collection, which may unlock cards.
+func openChest(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	username := currentUser(c).Username
+	u, err := auth.LoadUser(username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	held, ok := u.Chests.Get(c.Param("id"))
+	if !ok {
+		writeError(c, chest.ErrChestNotFound)
+		return
+	}
+	typ := held.Type
+	ch, _ := cat.Chest(typ)
+	owned := make(map[string]bool, len(u.Cards))
+	for _, name := range u.Cards {
+		owned[name] = true
+	}
+	loot := chest.Roll(cat, ch, func(troop string) bool { return owned[troop] })
+
+	// Take checks the chest is still there and ready, so the loot can't be
+	// paid twice
+	var unlocked []string
+	u, _, err = auth.Transact(username, loot.Wallet, wallet.ReasonChest, typ, func(u *auth.User) error {
+		if _, err := u.Chests.Take(c.Param("id"), chest.Clock()); err != nil {
+			return err
+		}
+		for name, n := range loot.Shards {
+			if u.Shards == nil {
+				u.Shards = make(map[string]int)
+			}
+			u.Shards[name] += n
+		}
+		unlocked = collection.Sync(cat, u)
+		return nil
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"type": typ, "loot": loot, "unlocked": unlocked, "wallet": u.Wallet})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/chest"
+	"clashroyale/internal/collection"
+	"clashroyale/internal/game"
+	"clashroyale/internal/lobby"
+	"clashroyale/internal/pass"
+	"clashroyale/internal/quest"
+	"clashroyale/internal/season"
+	"clashroyale/internal/tournament"
+	"clashroyale/internal/upgrade"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errForbidden is returned when the user's role doesn't allow an action.
+var errForbidden = errors.New("forbidden")
+
+// apiError describes how a domain error is reported to clients.
+type apiError struct {
+	err    error
+	status int
+	code   string
+}
+
+// apiErrors maps domain errors to HTTP status codes and stable codes the
+// browser can switch on. The first match wins.
+var apiErrors = []apiError{
+	{auth.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{auth.ErrUserExists, http.StatusConflict, "user_exists"},
+	{auth.ErrInvalidPassword, http.StatusUnauthorized, "invalid_password"},
+	{auth.ErrBanned, http.StatusForbidden, "banned"},
+	{auth.ErrSuspended, http.StatusForbidden, "suspended"},
+	{auth.ErrMuted, http.StatusForbidden, "muted"},
+	{auth.ErrInvalidSanction, http.StatusBadRequest, "invalid_sanction"},
+	{errForbidden, http.StatusForbidden, "forbidden"},
+	{game.ErrGameNotFound, http.StatusNotFound, "game_not_found"},
+	{game.ErrNotInGame, http.StatusForbidden, "not_in_game"},
+	{game.ErrGameFinished, http.StatusConflict, "game_finished"},
+	{game.ErrTimeUp, http.StatusConflict, "time_up"},
+	{game.ErrGameNotFinished, http.StatusConflict, "game_not_finished"},
+	{game.ErrRematchExpired, http.StatusGone, "rematch_expired"},
+	{game.ErrNotEnoughMana, http.StatusUnprocessableEntity, "not_enough_mana"},
+	{game.ErrTroopNotInHand, http.StatusUnprocessableEntity, "troop_not_in_hand"},
+	{game.ErrUnknownEmote, http.StatusBadRequest, "unknown_emote"},
+	{game.ErrEmoteTooSoon, http.StatusTooManyRequests, "emote_too_soon"},
+	{lobby.ErrRoomNotFound, http.StatusNotFound, "room_not_found"},
+	{lobby.ErrRoomExpired, http.StatusGone, "room_expired"},
+	{lobby.ErrRoomFull, http.StatusConflict, "room_full"},
+	{lobby.ErrNotInRoom, http.StatusForbidden, "not_in_room"},
+	{lobby.ErrAlreadyInGame, http.StatusConflict, "already_in_game"},
+	{lobby.ErrInvalidOptions, http.StatusBadRequest, "invalid_room_options"},
+	{lobby.ErrClosed, http.StatusServiceUnavailable, "shutting_down"},
+	{tournament.ErrTournamentNotFound, http.StatusNotFound, "tournament_not_found"},
+	{tournament.ErrRegistrationClosed, http.StatusConflict, "registration_closed"},
+	{tournament.ErrAlreadyRegistered, http.StatusConflict, "already_registered"},
+	{tournament.ErrNotRegistered, http.StatusConflict, "not_registered"},
+	{tournament.ErrTournamentFull, http.StatusConflict, "tournament_full"},
+	{tournament.ErrNotEnoughPlayers, http.StatusConflict, "not_enough_players"},
+	{tournament.ErrAlreadyStarted, http.StatusConflict, "tournament_started"},
+	{tournament.ErrInvalidConfig, http.StatusBadRequest, "invalid_tournament"},
+	{upgrade.ErrNotEnoughGold, http.StatusUnprocessableEntity, "not_enough_gold"},
+	{upgrade.ErrMaxLevel, http.StatusConflict, "max_level"},
+	{upgrade.ErrUnknownUnit, http.StatusNotFound, "unknown_unit"},
+	{collection.ErrLocked, http.StatusForbidden, "card_locked"},
+	{chest.ErrChestNotFound, http.StatusNotFound, "chest_not_found"},
+	{chest.ErrUnlockInProgress, http.StatusConflict, "chest_unlocking"},
+	{chest.ErrNotReady, http.StatusConflict, "chest_not_ready"},
+	{quest.ErrQuestNotFound, http.StatusNotFound, "quest_not_found"},
+	{quest.ErrNotComplete, http.StatusConflict, "quest_not_complete"},
+	{quest.ErrAlreadyClaimed, http.StatusConflict, "quest_claimed"},
+	{season.ErrSeasonNotFound, http.StatusNotFound, "season_not_found"},
+	{pass.ErrNoSeason, http.StatusConflict, "no_season"},
+	{pass.ErrTierNotFound, http.StatusNotFound, "pass_tier_not_found"},
+	{pass.ErrTierLocked, http.StatusConflict, "pass_tier_locked"},
+	{pass.ErrPremiumRequired, http.StatusForbidden, "premium_required"},
+	{pass.ErrAlreadyClaimed, http.StatusConflict, "pass_tier_claimed"},
+	{wallet.ErrInsufficientFunds, http.StatusUnprocessableEntity, "insufficient_funds"},
+	{wallet.ErrUnknownCurrency, http.StatusBadRequest, "unknown_currency"},
+}
+
+// writeError aborts the request with a JSON body of the form
+// {"error": "...", "code": "..."}. The message is localized from
+// error.<code> when the catalog has it. Unknown errors become a 500 and
+// are logged rather than shown to the user.
+func writeError(c *gin.Context, err error) {
+	for _, e := range apiErrors {
+		if errors.Is(err, e.err) {
+			c.AbortWithStatusJSON(e.status, gin.H{"error": errorText(c, e.code, err.Error()) + sanctionDetail(c, err), "code": e.code})
+			return
+		}
+	}
+	log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": errorText(c, "internal", "internal server error"), "code": "internal"})
+}
+
+// badRequest aborts with a 400 for malformed or unknown input.
+func badRequest(c *gin.Context, msg string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "code": "bad_request"})
+}
+
+// errorMessage returns the localized message for err, for pages that
+// show errors inline instead of as JSON.
+func errorMessage(c *gin.Context, err error) string {
+	for _, e := range apiErrors {
+		if errors.Is(err, e.err) {
+			return errorText(c, e.code, err.Error()) + sanctionDetail(c, err)
+		}
+	}
+	return err.Error()
+}
+
+// sanctionDetail adds when a sanction ends and why to its error message.
+func sanctionDetail(c *gin.Context, err error) string {
+	var se *auth.SanctionError
+	if !errors.As(err, &se) {
+		return ""
+	}
+	t := tr(c)
+	msg := ""
+	if !se.Sanction.Permanent() {
+		msg += " " + t.T("sanction.until", "time", se.Sanction.ExpiresAt.Format("2006-01-02 15:04 MST"))
+	}
+	if se.Sanction.Reason != "" {
+		msg += " " + t.T("sanction.reason", "reason", se.Sanction.Reason)
+	}
+	return msg
+}
+
+// errorText localizes an error code, falling back to fallback.
+func errorText(c *gin.Context, code, fallback string) string {
+	if t := tr(c); t.Has("error." + code) {
+		return t.T("error." + code)
+	}
+	return fallback
+}
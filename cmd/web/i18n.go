@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/i18n"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// localize picks the request's locale from the session (copied from the
+// user's profile at login) or Accept-Language, and stores a Translator
+// on the context.
+func localize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pref, _ := sessions.Default(c).Get("locale").(string)
+		c.Set("tr", i18n.For(i18n.Negotiate(pref, c.GetHeader("Accept-Language"))))
+		c.Next()
+	}
+}
+
+// tr returns the request's Translator.
+func tr(c *gin.Context) *i18n.Translator {
+	if t, ok := c.Get("tr"); ok {
+		return t.(*i18n.Translator)
+	}
+	return i18n.For(i18n.Default)
+}
+
+// render executes an HTML template with the request's Translator
+// available as .Tr, e.g. {{ .Tr.T "lobby.title" }}.
+func render(c *gin.Context, status int, name string, data gin.H) {
+	if data == nil {
+		data = gin.H{}
+	}
+	t := tr(c)
+	data["Tr"] = t
+	data["Lang"] = t.Locale
+	data["Locales"] = i18n.Locales()
+	c.HTML(status, name, data)
+}
+
+// setLocale saves the user's language preference to their profile.
+func setLocale(c *gin.Context) {
+	locale := c.PostForm("locale")
+	if locale != "" && !i18n.Supported(locale) {
+		badRequest(c, "unsupported locale")
+		return
+	}
+
+	sess := sessions.Default(c)
+	_, err := auth.Update(sess.Get("user").(string), func(u *auth.User) error {
+		u.Locale = locale
+		return nil
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	sess.Set("locale", locale)
+	sess.Save()
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
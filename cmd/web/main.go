@@ -2,10 +2,24 @@ package main
 
 import (
 	"clashroyale/internal/auth"
+	"clashroyale/internal/collection"
 	"clashroyale/internal/game"
-	"clashroyale/internal/model"
+	"clashroyale/internal/i18n"
+	"clashroyale/internal/leaderboard"
+	"clashroyale/internal/season"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/tournament"
 	"clashroyale/internal/upgrade"
+	"clashroyale/internal/wallet"
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -14,6 +28,55 @@ import (
 )
 
 func main() {
+	// Load and validate specs up front so a bad edit fails at boot, not mid-match
+	if _, err := spec.Current(); err != nil {
+		log.Fatalf("invalid specs: %v", err)
+	}
+	if err := i18n.Load(i18n.Dir); err != nil {
+		log.Fatalf("invalid locales: %v", err)
+	}
+	go spec.Watch(2*time.Second, nil)
+	promoteAdmins()
+	// the leaderboard starts from the saved accounts and follows every
+	// transaction from here on
+	if err := leaderboard.Load(); err != nil {
+		log.Fatalf("loading leaderboard: %v", err)
+	}
+	auth.OnTransact(leaderboard.Track)
+	if n, err := auth.MigrateWallets(); err != nil {
+		log.Fatalf("migrating wallets: %v", err)
+	} else if n > 0 {
+		log.Printf("moved %d accounts from EXP to wallets", n)
+	}
+
+	// Matches saved at the last shutdown go back in before tournaments
+	// load, so their series pick the games up again
+	n, err := game.RestoreMatches(game.SnapshotDir)
+	if err != nil {
+		log.Fatalf("restoring matches: %v", err)
+	}
+	if n > 0 {
+		log.Printf("restored %d matches from the last shutdown", n)
+	}
+
+	if tournaments, err = tournament.NewManager(tournament.Dir, game.GetLobbyManager()); err != nil {
+		log.Fatalf("loading tournaments: %v", err)
+	}
+	game.OnFinish(tournaments.HandleResult)
+	go tournaments.Run(5*time.Second, nil)
+
+	// seasons that ended while the server was down, or were part way
+	// through closing when it stopped, are closed before anyone can play
+	if seasons, err = season.NewManager(season.Dir); err != nil {
+		log.Fatalf("loading seasons: %v", err)
+	}
+	cat, _ := spec.Current()
+	if err := seasons.RollOver(cat, time.Now()); err != nil {
+		log.Fatalf("closing seasons: %v", err)
+	}
+	game.OnFinish(seasons.HandleResult)
+	go seasons.Run(5*time.Second, nil)
+
 	r := gin.Default()
 
 	r.Static("/static", "./templates/static")
@@ -26,7 +89,12 @@ func main() {
 		MaxAge:   int(24 * time.Hour / time.Second),
 		HttpOnly: true,
 	})
+	r.Use(instrument())
 	r.Use(sessions.Sessions("tcrsess", store))
+	r.Use(localize())
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", metricsHandler())
 
 	r.GET("/register", showRegister)
 	r.POST("/register", doRegister)
@@ -48,14 +116,34 @@ func main() {
 	})
 
 	r.GET("/lobby", authRequired(), func(c *gin.Context) {
-		c.HTML(http.StatusOK, "lobby.html", gin.H{
-			"QueueLen": game.GetLobbyManager().QueueLength(),
-		})
+		showLobby(c, http.StatusOK, nil)
 	})
 
 	r.POST("/lobby/join", authRequired(), func(c *gin.Context) {
 		user := sessions.Default(c).Get("user").(string)
-		if gameID := game.GetLobbyManager().Join(user); gameID != "" {
+		gameID, err := game.GetLobbyManager().Join(user)
+		if err != nil {
+			showLobby(c, http.StatusForbidden, err)
+			return
+		}
+		if gameID != "" {
+			c.Redirect(http.StatusSeeOther, "/game/"+gameID)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/lobby/wait")
+	})
+
+	// 2v2: queue solo for a random teammate, or name a partner to queue
+	// as a party once they name you back
+	r.POST("/lobby/join2v2", authRequired(), func(c *gin.Context) {
+		user := sessions.Default(c).Get("user").(string)
+		partner := strings.TrimSpace(c.PostForm("partner"))
+		gameID, err := game.GetLobbyManager().JoinTeams(user, partner)
+		if err != nil {
+			showLobby(c, http.StatusForbidden, err)
+			return
+		}
+		if gameID != "" {
 			c.Redirect(http.StatusSeeOther, "/game/"+gameID)
 			return
 		}
@@ -63,71 +151,288 @@ func main() {
 	})
 
 	r.GET("/lobby/wait", authRequired(), func(c *gin.Context) {
-		c.HTML(http.StatusOK, "wait.html", nil)
+		render(c, http.StatusOK, "wait.html", nil)
 	})
 
+	// Polled by the wait page; each poll doubles as a presence heartbeat
 	r.GET("/lobby/status", authRequired(), func(c *gin.Context) {
 		user := sessions.Default(c).Get("user").(string)
-		if gameID := game.GetLobbyManager().GetGame(user); gameID != "" {
-			c.JSON(http.StatusOK, gin.H{"gameID": gameID})
-		} else {
-			c.JSON(http.StatusOK, gin.H{"gameID": ""})
-		}
+		lm := game.GetLobbyManager()
+		lm.Heartbeat(user)
+		c.JSON(http.StatusOK, lm.Status(user))
+	})
+
+	r.POST("/lobby/leave", authRequired(), func(c *gin.Context) {
+		user := sessions.Default(c).Get("user").(string)
+		game.GetLobbyManager().Leave(user)
+		c.Redirect(http.StatusSeeOther, "/lobby")
 	})
 
+	// Private rooms for friend challenges
+	r.POST("/rooms", authRequired(), createRoom)
+	r.POST("/rooms/join", authRequired(), joinRoomByCode)
+	r.GET("/rooms/:code", authRequired(), showRoom)
+	r.GET("/rooms/:code/status", authRequired(), roomStatus)
+	r.POST("/rooms/:code/ready", authRequired(), roomReady)
+	r.POST("/rooms/:code/leave", authRequired(), leaveRoom)
+
 	r.GET("/game/:gameID", authRequired(), func(c *gin.Context) {
 		id := c.Param("gameID")
-		players := game.GetLobbyManager().GetPlayers(id)
-		c.HTML(http.StatusOK, "game.html", gin.H{
-			"GameID":  id,
-			"Players": players,
+		match, _ := game.GetLobbyManager().GetMatch(id)
+		var titles, arenas map[string]string
+		if cat, err := spec.Current(); err == nil {
+			titles = achievementTitles(tr(c), cat)
+			arenas = arenaNames(tr(c), cat)
+		}
+		render(c, http.StatusOK, "game.html", gin.H{
+			"GameID":       id,
+			"Players":      match.Players,
+			"Username":     currentUser(c).Username,
+			"Achievements": titles,
+			"Arenas":       arenas,
+			"Emotes":       game.Emotes,
 		})
 	})
 
 	r.GET("/game/:gameID/state", authRequired(), func(c *gin.Context) {
-		gs := game.GetOrCreate(c.Param("gameID"))
+		gs, err := game.GetOrCreate(c.Param("gameID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
 		user := sessions.Default(c).Get("user").(string)
+		if !gs.HasPlayer(user) {
+			writeError(c, game.ErrNotInGame)
+			return
+		}
 
-		gs.RegenMana(user)
-		if time.Since(gs.StartTime) > gs.Duration && !gs.IsFinished {
-			gs.FinishGame()
+		after, _ := strconv.Atoi(c.Query("after"))
+		if gs.Touch(user) {
+			// back from a disconnect: resend everything
+			after = 0
+		}
+		// does nothing if the game is already over
+		if time.Since(gs.StartTime) > gs.Duration {
+			if err := gs.FinishGame(game.ReasonTimeUp); err != nil {
+				writeError(c, err)
+				return
+			}
 		}
-		c.JSON(http.StatusOK, gs.Snapshot(user))
+		c.JSON(http.StatusOK, gs.Snapshot(user, after, tr(c)))
+	})
+
+	r.POST("/game/:gameID/surrender", authRequired(), func(c *gin.Context) {
+		user := sessions.Default(c).Get("user").(string)
+		gs, err := game.GetOrCreate(c.Param("gameID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if err := gs.Surrender(user); err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	r.POST("/game/:gameID/rematch", authRequired(), func(c *gin.Context) {
+		user := sessions.Default(c).Get("user").(string)
+		gs, err := game.GetOrCreate(c.Param("gameID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		id, err := gs.OfferRematch(user)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"rematchID": id})
+	})
+
+	// Raw battle event stream, for clients and analytics that want to
+	// render or aggregate events themselves. Only players in the game can
+	// read it, and reading it doesn't start the game.
+	r.GET("/game/:gameID/events", authRequired(), func(c *gin.Context) {
+		gs, err := game.Get(c.Param("gameID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		user := sessions.Default(c).Get("user").(string)
+		if !gs.HasPlayer(user) {
+			writeError(c, game.ErrNotInGame)
+			return
+		}
+		after, _ := strconv.Atoi(c.Query("after"))
+		c.JSON(http.StatusOK, gin.H{"events": gs.EventsSince(after)})
 	})
 
 	r.POST("/game/:gameID/deploy", authRequired(), func(c *gin.Context) {
 		tr := c.PostForm("troop")
 		user := sessions.Default(c).Get("user").(string)
-		err := game.GetOrCreate(c.Param("gameID")).Deploy(user, tr)
+		gs, err := game.GetOrCreate(c.Param("gameID"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusOK, gin.H{"success": true})
+			writeError(c, err)
+			return
 		}
+		gs.Touch(user)
+		if err := gs.Deploy(user, tr); err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true})
 	})
 
+	// Emotes show up in the battle log; muted players can't send them
+	r.POST("/game/:gameID/emote", authRequired(), func(c *gin.Context) {
+		user := sessions.Default(c).Get("user").(string)
+		gs, err := game.GetOrCreate(c.Param("gameID"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if err := gs.Emote(user, c.PostForm("emote")); err != nil {
+			writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	// Tournaments: browse, register, and follow the bracket
+	r.GET("/tournaments", authRequired(), listTournaments)
+	r.GET("/tournaments/:id", authRequired(), showTournament)
+	r.GET("/tournaments/:id/bracket", authRequired(), tournamentBracket)
+	r.POST("/tournaments/:id/register", authRequired(), registerTournament)
+	r.POST("/tournaments/:id/unregister", authRequired(), unregisterTournament)
+
+	// Admin console. Moderators can look around, sanction players and end stuck
+	// matches; changing balances, roles, specs or tournaments needs an admin.
+	mod := r.Group("/admin", authRequired(), roleRequired(auth.RoleModerator))
+	mod.GET("", showAdmin)
+	mod.GET("/players", searchPlayers)
+	mod.GET("/players/:username/ledger", playerLedger)
+	mod.POST("/players/:username/sanctions", imposeSanction)
+	mod.POST("/players/:username/sanctions/lift", liftSanction)
+	mod.GET("/games", listGames)
+	mod.POST("/games/:gameID/finish", forceFinish)
+	mod.GET("/lobby", lobbyOverview)
+	mod.GET("/audit", auditTrail)
+
+	admin := mod.Group("", roleRequired(auth.RoleAdmin))
+	admin.POST("/players/:username", updatePlayer)
+	admin.POST("/players/:username/role", setRole)
+	admin.POST("/players/:username/pass", grantPass)
+	admin.POST("/specs/reload", reloadSpecs)
+	admin.POST("/tournaments", createTournament)
+	admin.POST("/tournaments/:id/start", startTournament)
+	admin.POST("/tournaments/:id/cancel", cancelTournament)
+
+	r.POST("/profile/locale", authRequired(), setLocale)
+
+	r.GET("/wallet", authRequired(), showWallet)
+	r.GET("/cards", authRequired(), showCards)
+	r.GET("/arenas", authRequired(), showArenas)
+	r.GET("/chests", authRequired(), showChests)
+	r.POST("/chests/:id/unlock", authRequired(), unlockChest)
+	r.POST("/chests/:id/open", authRequired(), openChest)
+	r.GET("/quests", authRequired(), showQuests)
+	r.POST("/quests/:id/claim", authRequired(), claimQuest)
+	r.GET("/pass", authRequired(), showPass)
+	r.POST("/pass/:track/:tier/claim", authRequired(), claimPass)
+	r.GET("/players/:username", showProfile)
+	r.GET("/leaderboard", showLeaderboard)
+	r.GET("/seasons/:id", showSeason)
+
+	r.GET("/upgrades", authRequired(), upgradePreviews)
 	r.POST("/upgrade/troop", authRequired(), upgradeTroop)
 	r.POST("/upgrade/tower", authRequired(), upgradeTower)
 
-	r.Run(":8080")
+	serve(&http.Server{Addr: ":8080", Handler: r})
 }
 
-// Middleware to require login
+// shutdownDrain is how long running matches get to finish after a
+// shutdown signal before they're saved for the next start.
+const shutdownDrain = 10 * time.Second
+
+// serve runs srv until SIGINT or SIGTERM, then shuts down gracefully: the
+// lobby stops making matches, running ones get shutdownDrain to end, and
+// whatever is left is saved to be restored on the next start.
+func serve(srv *http.Server) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %v", err)
+		}
+	}()
+	<-ctx.Done()
+	stop() // a second signal kills the process straight away
+
+	log.Printf("shutting down: lobby closed, waiting up to %s for matches to finish", shutdownDrain)
+	game.Drain(shutdownDrain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http shutdown: %v", err)
+	}
+
+	n, err := game.SaveMatches(game.SnapshotDir)
+	if err != nil {
+		log.Printf("saving matches: %v", err)
+	}
+	log.Printf("saved %d unfinished matches", n)
+}
+
+// Middleware to require login. The user is reloaded on every request,
+// so a ban (or a deleted account) ends the session on the next click
+// rather than when the cookie expires.
 func authRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		sess := sessions.Default(c)
-		user := sess.Get("user")
-		if user == nil {
+		user, _ := sess.Get("user").(string)
+		if user == "" {
 			c.Redirect(http.StatusSeeOther, "/login")
 			c.Abort()
 			return
 		}
+		u, err := auth.LoadUser(user)
+		if err == nil {
+			err = u.CheckLogin(time.Now())
+		}
+		if err != nil {
+			sess.Clear()
+			sess.Save()
+			c.Redirect(http.StatusSeeOther, "/login")
+			c.Abort()
+			return
+		}
+		c.Set("currentUser", u)
+		c.Next()
+	}
+}
+
+// currentUser is the user authRequired loaded for this request.
+func currentUser(c *gin.Context) *auth.User {
+	return c.MustGet("currentUser").(*auth.User)
+}
+
+// roleRequired lets through only users with role or a more powerful
+// one. Use after authRequired.
+func roleRequired(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !currentUser(c).HasRole(role) {
+			writeError(c, errForbidden)
+			return
+		}
 		c.Next()
 	}
 }
 
 func showRegister(c *gin.Context) {
-	c.HTML(http.StatusOK, "register.html", nil)
+	render(c, http.StatusOK, "register.html", nil)
 }
 
 func doRegister(c *gin.Context) {
@@ -135,14 +440,14 @@ func doRegister(c *gin.Context) {
 	password := c.PostForm("password")
 
 	if _, err := auth.Register(username, password); err != nil {
-		c.HTML(http.StatusBadRequest, "register.html", gin.H{"Error": err.Error()})
+		render(c, http.StatusBadRequest, "register.html", gin.H{"Error": errorMessage(c, err)})
 		return
 	}
 	c.Redirect(http.StatusSeeOther, "/login")
 }
 
 func showLogin(c *gin.Context) {
-	c.HTML(http.StatusOK, "login.html", nil)
+	render(c, http.StatusOK, "login.html", nil)
 }
 
 func doLogin(c *gin.Context) {
@@ -151,12 +456,13 @@ func doLogin(c *gin.Context) {
 
 	user, err := auth.Authenticate(username, password)
 	if err != nil {
-		c.HTML(http.StatusUnauthorized, "login.html", gin.H{"Error": err.Error()})
+		render(c, http.StatusUnauthorized, "login.html", gin.H{"Error": errorMessage(c, err)})
 		return
 	}
 
 	sess := sessions.Default(c)
 	sess.Set("user", user.Username)
+	sess.Set("locale", user.Locale)
 	sess.Save()
 
 	c.Redirect(http.StatusSeeOther, "/dashboard")
@@ -164,172 +470,155 @@ func doLogin(c *gin.Context) {
 
 func dashboard(c *gin.Context) {
 	username := sessions.Default(c).Get("user").(string)
-	player := game.LoadPlayer(username)
-
-	// Load troops for upgrade display
-	troops, err := game.LoadTroops()
+	cat, err := spec.Current()
 	if err != nil {
-		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": "Failed to load troops"})
+		writeError(c, err)
 		return
 	}
-
-	// Prepare troop data for display
-	var troopData []gin.H
-	for _, base := range troops {
-		lvl := player.TroopLevels[base.Name]
-		canUpgrade, cost := upgrade.CanUpgradeTroop(player, base)
-
-		// **re‐calculate** stats from the ORIGINAL base JSON values
-		hp := upgrade.CalculateUpgradeStats(base.HP, lvl)
-		atk := upgrade.CalculateUpgradeStats(base.ATK, lvl)
-		def := upgrade.CalculateUpgradeStats(base.DEF, lvl)
-
-		troopData = append(troopData, gin.H{
-			"Name":        base.Name,
-			"HP":          hp,
-			"ATK":         atk,
-			"DEF":         def,
-			"Level":       lvl,
-			"UpgradeCost": cost,
-			"CanUpgrade":  canUpgrade,
-		})
-	}
-
-	// Prepare tower data for display (already upgraded in LoadPlayer)
-	var towerData []gin.H
-	for _, tower := range player.Towers {
-		lvl := player.TowerLevels[tower.Name]
-		canUpgrade, cost := upgrade.CanUpgradeTower(player, tower)
-
-		towerData = append(towerData, gin.H{
-			"Name":        tower.Name,
-			"HP":          tower.HP,  // use the mutated HP
-			"ATK":         tower.ATK, // use the mutated ATK
-			"DEF":         tower.DEF, // use the mutated DEF
-			"Level":       lvl,
-			"UpgradeCost": cost,
-			"CanUpgrade":  canUpgrade,
-		})
-	}
-
-	c.HTML(http.StatusOK, "dashboard.html", gin.H{
-		"Username": username,
-		"Exp":      player.Exp,
-		"Level":    player.Level,
-		"Troops":   troopData,
-		"Towers":   towerData,
-	})
-}
-
-// Add upgrade endpoints
-func upgradeTroop(c *gin.Context) {
-	username := sessions.Default(c).Get("user").(string)
-	player := game.LoadPlayer(username)
-	troopName := c.PostForm("name")
-
-	// Find the troop
-	troops, err := game.LoadTroops()
+	// save any cards the player has become eligible for
+	user, _, err := collection.Refresh(cat, username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load troops"})
+		writeError(c, err)
 		return
 	}
-
-	var targetTroop *model.Troop
-	for _, troop := range troops {
-		if troop.Name == troopName {
-			targetTroop = troop
-			break
-		}
+	if user, err = refreshQuests(cat, username); err != nil {
+		writeError(c, err)
+		return
 	}
-
-	if targetTroop == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Troop not found"})
+	player, err := game.LoadPlayer(username)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
-	// Attempt upgrade
-	success, err := upgrade.UpgradeTroop(player, targetTroop)
+	// Levels, stats and next-level costs come from the upgrade curves
+	troops, towers, err := upgrade.Previews(cat, player)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	if !success {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not enough EXP or cannot upgrade"})
+	lastID, last := lastStanding(tr(c), username)
+
+	render(c, http.StatusOK, "dashboard.html", gin.H{
+		"Username": username,
+		"IsStaff":  currentUser(c).HasRole(auth.RoleModerator),
+		"Gold":     player.Wallet[wallet.Gold],
+		"Exp":      player.Wallet[wallet.Exp],
+		"Trophies": player.Wallet[wallet.Trophies],
+		"Level":    player.Level,
+		"Troops":   troops,
+		"Towers":   towers,
+		"Cards":    collection.List(cat, user),
+		"Chests":   viewChests(cat, user),
+		"Empty":    make([]struct{}, max(0, cat.ChestSlots()-len(user.Chests))),
+		"Quests":   viewQuests(tr(c), cat, user),
+		"Arena":    viewArenaProgress(tr(c), cat, player.Wallet[wallet.Trophies]),
+		"Season":   viewSeason(tr(c), cat, user),
+		"Pass":     viewPass(tr(c), cat, user),
+		"LastID":   lastID,
+		"Last":     last,
+	})
+}
+
+// upgradePreviews lists every troop and tower with the player's level,
+// its stats now and at the next level, and what that level costs.
+func upgradePreviews(c *gin.Context) {
+	player, err := game.LoadPlayer(currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
-
-	// Load existing user data to preserve password hash
-	user, err := auth.LoadUser(username)
+	cat, err := spec.Current()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user data"})
+		writeError(c, err)
 		return
 	}
-
-	// Update user data while preserving password hash
-	user.Exp = player.Exp
-	user.Level = player.Level
-	user.TroopLevels = player.TroopLevels
-	user.TowerLevels = player.TowerLevels
-
-	// Save updated player data
-	if err := auth.SaveUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save player data"})
+	troops, towers, err := upgrade.Previews(cat, player)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"gold": player.Wallet[wallet.Gold], "troops": troops, "towers": towers})
+}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+// Add upgrade endpoints
+func upgradeTroop(c *gin.Context) {
+	upgradeUnit(c, spec.KindTroop)
 }
 
 func upgradeTower(c *gin.Context) {
-	username := sessions.Default(c).Get("user").(string)
-	player := game.LoadPlayer(username)
-	towerName := c.PostForm("name")
-
-	// Find the tower
-	var targetTower *model.Tower
-	for _, tower := range player.Towers {
-		if tower.Name == towerName {
-			targetTower = tower
-			break
-		}
-	}
+	upgradeUnit(c, spec.KindTower)
+}
 
-	if targetTower == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Tower not found"})
-		return
-	}
+// errStaleUpgrade means the unit's level moved between pricing an
+// upgrade and paying for it, e.g. two upgrades sent at once.
+var errStaleUpgrade = errors.New("unit level changed during upgrade")
 
-	// Attempt upgrade
-	success, err := upgrade.UpgradeTower(player, targetTower)
+// upgradeUnit levels up the troop or tower named in the form, charging
+// the gold and saving the new level together. If another upgrade lands
+// first, it prices the next level again and retries.
+func upgradeUnit(c *gin.Context, kind string) {
+	username := sessions.Default(c).Get("user").(string)
+	cat, err := spec.Current()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
+	name := c.PostForm("name")
 
-	if !success {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not enough EXP or cannot upgrade"})
-		return
+	levelUp, preview := upgrade.UpgradeTroop, upgrade.PreviewTroop
+	if kind == spec.KindTower {
+		levelUp, preview = upgrade.UpgradeTower, upgrade.PreviewTower
 	}
-
-	// Load existing user data to preserve password hash
-	user, err := auth.LoadUser(username)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user data"})
-		return
+	levels := func(u *auth.User) map[string]int {
+		if kind == spec.KindTower {
+			if u.TowerLevels == nil {
+				u.TowerLevels = make(map[string]int)
+			}
+			return u.TowerLevels
+		}
+		if u.TroopLevels == nil {
+			u.TroopLevels = make(map[string]int)
+		}
+		return u.TroopLevels
 	}
-
-	// Update user data while preserving password hash
-	user.Exp = player.Exp
-	user.Level = player.Level
-	user.TroopLevels = player.TroopLevels
-	user.TowerLevels = player.TowerLevels
-
-	// Save updated player data
-	if err := auth.SaveUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save player data"})
+	for {
+		player, err := game.LoadPlayer(username)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		before := player.TroopLevels[name]
+		if kind == spec.KindTower {
+			before = player.TowerLevels[name]
+		}
+		cost, err := levelUp(cat, player, name)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		after := player.TroopLevels[name]
+		if kind == spec.KindTower {
+			after = player.TowerLevels[name]
+		}
+		_, _, err = auth.Transact(username, wallet.Balances{wallet.Gold: -cost}, wallet.ReasonUpgrade, name, func(u *auth.User) error {
+			// missing levels load as 1, see game.LoadPlayer
+			if level, ok := levels(u)[name]; (ok && level != before) || (!ok && before != 1) {
+				return errStaleUpgrade
+			}
+			levels(u)[name] = after
+			return nil
+		})
+		if errors.Is(err, errStaleUpgrade) {
+			continue
+		}
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		unit, _ := preview(cat, player, name)
+		c.JSON(http.StatusOK, gin.H{"success": true, "unit": unit})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"success": true})
 }
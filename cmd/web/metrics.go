@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var httpRequestSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "clashroyale_http_request_duration_seconds",
+	Help:    "HTTP request latency, by route and status.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// instrument records every request's latency and status under its route
+// pattern (e.g. /game/:gameID/state), so IDs don't explode the labels.
+func instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestSeconds.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// metricsHandler serves everything registered with the default
+// Prometheus registry, plus Go runtime and process stats.
+func metricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/i18n"
+	"clashroyale/internal/pass"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passRewardView is what one track pays for a tier.
+type passRewardView struct {
+	Reward    wallet.Balances `json:"reward,omitempty"`
+	Prize     string          `json:"prize,omitempty"` // Reward, described
+	Claimed   bool            `json:"claimed"`
+	Claimable bool            `json:"claimable"`
+}
+
+// passTierView is one tier of the battle pass.
+type passTierView struct {
+	Tier    int            `json:"tier"`
+	XP      int            `json:"xp"` // pass XP it takes
+	Reached bool           `json:"reached"`
+	Free    passRewardView `json:"free"`
+	Premium passRewardView `json:"premium"`
+}
+
+// passView is a player's battle pass for the season under way.
+type passView struct {
+	Season  string         `json:"season"`
+	Premium bool           `json:"premium"`
+	XP      int            `json:"xp"`
+	Tier    int            `json:"tier"`    // tiers reached
+	ToNext  int            `json:"toNext"`  // pass XP still needed for the next tier, 0 once all are reached
+	Percent int            `json:"percent"` // of the way to the next tier
+	Tiers   []passTierView `json:"tiers"`
+}
+
+// viewPass describes u's battle pass. It returns nil between seasons.
+func viewPass(t *i18n.Translator, cat *spec.Catalog, u *auth.User) *passView {
+	s, ok := cat.SeasonAt(time.Now())
+	if !ok {
+		return nil
+	}
+	// a pass left over from an earlier season shows as a fresh one
+	p := u.Pass
+	p.Rotate(s.ID)
+
+	xp, step := u.Wallet[wallet.PassXP], cat.PassTierXP()
+	v := &passView{Season: s.ID, Premium: p.Premium, XP: xp, Tier: cat.PassTierAt(xp)}
+	tiers := cat.Pass()
+	if v.Tier < len(tiers) {
+		v.ToNext = (v.Tier+1)*step - xp
+		v.Percent = 100 * (step - v.ToNext) / step
+	}
+	for i := range tiers {
+		n := i + 1
+		tv := passTierView{Tier: n, XP: n * step, Reached: n <= v.Tier}
+		for _, track := range spec.Tracks {
+			reward, _ := cat.PassReward(track, n)
+			if len(reward) == 0 {
+				continue
+			}
+			_, err := p.Reward(cat, track, n, xp)
+			rv := passRewardView{Reward: reward, Prize: prize(t, reward), Claimed: p.Has(track, n), Claimable: err == nil}
+			if track == spec.TrackFree {
+				tv.Free = rv
+			} else {
+				tv.Premium = rv
+			}
+		}
+		v.Tiers = append(v.Tiers, tv)
+	}
+	return v
+}
+
+// currentSeason closes any season that has just ended, so a pass can't
+// be used with the last season's pass XP, and returns the one under way.
+func currentSeason(cat *spec.Catalog) (spec.Season, error) {
+	now := time.Now()
+	if err := seasons.RollOver(cat, now); err != nil {
+		return spec.Season{}, err
+	}
+	s, ok := cat.SeasonAt(now)
+	if !ok {
+		return spec.Season{}, pass.ErrNoSeason
+	}
+	return s, nil
+}
+
+// showPass returns the current player's battle pass.
+func showPass(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if _, err := currentSeason(cat); err != nil {
+		writeError(c, err)
+		return
+	}
+	u, err := auth.LoadUser(currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewPass(tr(c), cat, u))
+}
+
+// claimPass pays out a reached tier on the free or premium track.
+func claimPass(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	s, err := currentSeason(cat)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	track := c.Param("track")
+	tier, err := strconv.Atoi(c.Param("tier"))
+	if err != nil {
+		writeError(c, pass.ErrTierNotFound)
+		return
+	}
+	username := currentUser(c).Username
+	u, err := auth.LoadUser(username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	// work out the reward on a copy first, Transact has to know it up front
+	p := u.Pass
+	p.Rotate(s.ID)
+	reward, err := p.Reward(cat, track, tier, u.Wallet[wallet.PassXP])
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	ref := fmt.Sprintf("%s/%s/%d", s.ID, track, tier)
+	u, _, err = auth.Transact(username, reward, wallet.ReasonPass, ref, func(u *auth.User) error {
+		u.Pass.Rotate(s.ID)
+		_, err := u.Pass.Claim(cat, track, tier, u.Wallet[wallet.PassXP])
+		return err
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reward": reward, "wallet": u.Wallet})
+}
+
+// Admin API
+
+// grantPass gives a player the premium battle pass for the season under
+// way. Granting it twice changes nothing.
+func grantPass(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	s, err := currentSeason(cat)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	u, err := auth.Update(c.Param("username"), func(u *auth.User) error {
+		u.Pass.Rotate(s.ID)
+		u.Pass.Premium = true
+		return nil
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "grant_pass", u.Username, gin.H{"season": s.ID})
+	c.JSON(http.StatusOK, viewPass(tr(c), cat, u))
+}
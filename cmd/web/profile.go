@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/i18n"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/stats"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// achievementView is an achievement from the catalog, with how far one
+// player has got towards it.
+type achievementView struct {
+	spec.Achievement
+	Title    string     `json:"title"`
+	Text     string     `json:"text"`
+	Progress int        `json:"progress"` // capped at Threshold
+	Percent  int        `json:"percent"`
+	EarnedAt *time.Time `json:"earnedAt,omitempty"`
+}
+
+// profileView is what anyone can see about a player. It leaves out
+// everything private: password, wallet, ledger and sanctions.
+type profileView struct {
+	Username     string            `json:"username"`
+	Level        int               `json:"level"`
+	Trophies     int               `json:"trophies"`
+	Arena        string            `json:"arena"`
+	Cards        int               `json:"cards"`
+	Stats        stats.Stats       `json:"stats"`
+	WinRate      int               `json:"winRate"` // percent of matches won
+	Favorite     string            `json:"favoriteTroop,omitempty"`
+	Earned       int               `json:"earned"`
+	Achievements []achievementView `json:"achievements"`
+}
+
+// achievementTitle names an achievement, falling back to its ID for ones
+// added to the specs without a translation.
+func achievementTitle(t *i18n.Translator, id string) string {
+	if t.Has("achievement." + id) {
+		return t.T("achievement." + id)
+	}
+	return id
+}
+
+// achievementTitles names every achievement in cat, by ID.
+func achievementTitles(t *i18n.Translator, cat *spec.Catalog) map[string]string {
+	titles := make(map[string]string)
+	for _, a := range cat.Achievements() {
+		titles[a.ID] = achievementTitle(t, a.ID)
+	}
+	return titles
+}
+
+// viewAchievements lists every achievement in cat, earned ones first in
+// the order u earned them.
+func viewAchievements(t *i18n.Translator, cat *spec.Catalog, u *auth.User) []achievementView {
+	at := make(map[string]time.Time, len(u.Achievements))
+	for _, e := range u.Achievements {
+		at[e.ID] = e.At
+	}
+	view := func(a spec.Achievement) achievementView {
+		v := achievementView{
+			Achievement: a,
+			Title:       achievementTitle(t, a.ID),
+			Text:        t.T("achievement.goal", "stat", t.T("stat."+a.Stat), "threshold", a.Threshold),
+			Progress:    min(a.Threshold, u.Stats.Value(a.Stat)),
+		}
+		v.Percent = 100 * v.Progress / a.Threshold
+		if when, ok := at[a.ID]; ok {
+			v.EarnedAt = &when
+		}
+		return v
+	}
+
+	byID := make(map[string]spec.Achievement)
+	for _, a := range cat.Achievements() {
+		byID[a.ID] = a
+	}
+	out := []achievementView{}
+	for _, e := range u.Achievements {
+		if a, ok := byID[e.ID]; ok {
+			out = append(out, view(a))
+		}
+	}
+	for _, a := range cat.Achievements() {
+		if _, ok := at[a.ID]; !ok {
+			out = append(out, view(a))
+		}
+	}
+	return out
+}
+
+func viewProfile(t *i18n.Translator, cat *spec.Catalog, u *auth.User) profileView {
+	p := profileView{
+		Username:     u.Username,
+		Level:        u.Level,
+		Trophies:     u.Wallet[wallet.Trophies],
+		Arena:        viewArenaProgress(t, cat, u.Wallet[wallet.Trophies]).Arena.Name,
+		Cards:        len(u.Cards),
+		Stats:        u.Stats,
+		Favorite:     u.Stats.Favorite(),
+		Achievements: viewAchievements(t, cat, u),
+	}
+	if u.Stats.Matches > 0 {
+		p.WinRate = 100 * u.Stats.Wins / u.Stats.Matches
+	}
+	for _, a := range p.Achievements {
+		if a.EarnedAt != nil {
+			p.Earned++
+		}
+	}
+	return p
+}
+
+// showProfile is a player's public profile: their lifetime stats and
+// achievements. It needs no login, and answers in JSON to clients that
+// ask for it.
+func showProfile(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	u, err := auth.LoadUser(c.Param("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	p := viewProfile(tr(c), cat, u)
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(http.StatusOK, p)
+		return
+	}
+	viewer, _ := sessions.Default(c).Get("user").(string)
+	render(c, http.StatusOK, "profile.html", gin.H{"P": p, "Viewer": viewer})
+}
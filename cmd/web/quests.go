@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/i18n"
+	"clashroyale/internal/quest"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// questView is a quest on a player's board, described in their language.
+type questView struct {
+	quest.Entry
+	Text     string          `json:"text"`
+	Count    int             `json:"count"`
+	Reward   wallet.Balances `json:"reward"`
+	Prize    string          `json:"prize"`  // Reward, described
+	EndsIn   int             `json:"endsIn"` // seconds until it rotates out
+	Percent  int             `json:"percent"`
+	Complete bool            `json:"complete"`
+}
+
+// prize describes a reward, e.g. "100 Gold, 20 EXP".
+func prize(t *i18n.Translator, reward wallet.Balances) string {
+	var parts []string
+	for _, cur := range wallet.Currencies {
+		if reward[cur] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", reward[cur], t.T("currency."+string(cur))))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// viewQuests describes u's board, skipping quests that have left cat.
+func viewQuests(t *i18n.Translator, cat *spec.Catalog, u *auth.User) []questView {
+	now := quest.Clock()
+	out := []questView{}
+	for _, e := range u.Quests {
+		q, ok := cat.Quest(e.ID)
+		if !ok {
+			continue
+		}
+		key := "quest." + q.Kind
+		if q.Target != "" {
+			key += "_target"
+		}
+		out = append(out, questView{
+			Entry:    e,
+			Text:     t.T(key, "count", q.Count, "target", q.Target),
+			Count:    q.Count,
+			Reward:   q.Reward,
+			Prize:    prize(t, q.Reward),
+			EndsIn:   int(quest.Ends(e.Period, now).Sub(now) / time.Second),
+			Percent:  100 * e.Progress / q.Count,
+			Complete: e.Progress >= q.Count,
+		})
+	}
+	return out
+}
+
+// refreshQuests deals username a new set of quests if their day or week
+// has rolled over, and returns the account.
+func refreshQuests(cat *spec.Catalog, username string) (*auth.User, error) {
+	u, err := auth.LoadUser(username)
+	if err != nil {
+		return nil, err
+	}
+	if !u.Quests.Rotate(cat, quest.Clock()) {
+		return u, nil
+	}
+	u, err = auth.Update(username, func(u *auth.User) error {
+		u.Quests.Rotate(cat, quest.Clock())
+		return nil
+	})
+	return u, err
+}
+
+// showQuests returns the current player's daily and weekly quests.
+func showQuests(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	u, err := refreshQuests(cat, currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quests": viewQuests(tr(c), cat, u)})
+}
+
+// claimQuest pays out a completed quest.
+func claimQuest(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	username, id := currentUser(c).Username, c.Param("id")
+	u, err := refreshQuests(cat, username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	// work out the reward on a copy first, Transact has to know it up front
+	board := append(quest.Board(nil), u.Quests...)
+	reward, err := board.Claim(cat, id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	u, _, err = auth.Transact(username, reward, wallet.ReasonQuest, id, func(u *auth.User) error {
+		u.Quests.Rotate(cat, quest.Clock())
+		_, err := u.Quests.Claim(cat, id)
+		return err
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reward": reward, "wallet": u.Wallet})
+}
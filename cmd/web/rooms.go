@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clashroyale/internal/game"
+	"clashroyale/internal/lobby"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// showLobby renders the lobby page, optionally with an error message.
+func showLobby(c *gin.Context, status int, err error) {
+	data := gin.H{
+		"QueueLen":        game.GetLobbyManager().QueueLength(),
+		"DefaultDuration": int(lobby.DefaultDuration / time.Minute),
+		"MinDuration":     int(lobby.MinDuration / time.Minute),
+		"MaxDuration":     int(lobby.MaxDuration / time.Minute),
+	}
+	if err != nil {
+		data["Error"] = errorMessage(c, err)
+	}
+	render(c, status, "lobby.html", data)
+}
+
+// createRoom opens a private room and sends the host to it.
+func createRoom(c *gin.Context) {
+	user := sessions.Default(c).Get("user").(string)
+	minutes, _ := strconv.Atoi(c.PostForm("duration"))
+	opts := lobby.Options{
+		Duration: time.Duration(minutes) * time.Minute,
+		Mode:     c.PostForm("mode"),
+	}
+
+	room, err := game.GetLobbyManager().CreateRoom(user, opts)
+	if err != nil {
+		showLobby(c, http.StatusBadRequest, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/rooms/"+room.Code)
+}
+
+// joinRoomByCode handles the "enter a code" form on the lobby page.
+func joinRoomByCode(c *gin.Context) {
+	code := strings.ToUpper(strings.TrimSpace(c.PostForm("code")))
+	c.Redirect(http.StatusSeeOther, "/rooms/"+code)
+}
+
+// showRoom is also the invite link: opening it joins the room.
+func showRoom(c *gin.Context) {
+	user := sessions.Default(c).Get("user").(string)
+	room, err := game.GetLobbyManager().JoinRoom(c.Param("code"), user)
+	if err != nil {
+		showLobby(c, http.StatusNotFound, err)
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	render(c, http.StatusOK, "room.html", gin.H{
+		"Room":     room,
+		"Minutes":  int(room.Options.Duration / time.Minute),
+		"Invite":   scheme + "://" + c.Request.Host + "/rooms/" + room.Code,
+		"Username": user,
+	})
+}
+
+func roomStatus(c *gin.Context) {
+	room, err := game.GetLobbyManager().GetRoom(c.Param("code"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+func roomReady(c *gin.Context) {
+	user := sessions.Default(c).Get("user").(string)
+	ready := c.PostForm("ready") == "true"
+	room, err := game.GetLobbyManager().SetReady(c.Param("code"), user, ready)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+func leaveRoom(c *gin.Context) {
+	user := sessions.Default(c).Get("user").(string)
+	if err := game.GetLobbyManager().LeaveRoom(c.Param("code"), user); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/lobby")
+}
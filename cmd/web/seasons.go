@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/i18n"
+	"clashroyale/internal/leaderboard"
+	"clashroyale/internal/season"
+	"clashroyale/internal/spec"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+var seasons *season.Manager
+
+// leaderboardSize is how many places the leaderboard shows.
+const leaderboardSize = 100
+
+// seasonView is the season under way and, for a logged-in player, where
+// they stand in it.
+type seasonView struct {
+	spec.Season
+	EndsIn       int    `json:"endsIn"` // seconds
+	Rank         int    `json:"rank,omitempty"`
+	Matches      int    `json:"matches"`
+	PeakTrophies int    `json:"peakTrophies"`
+	PeakRank     int    `json:"peakRank,omitempty"`
+	Reward       string `json:"reward,omitempty"` // what PeakRank would earn if the season ended now
+}
+
+// viewSeason describes the current season for u, who may be nil. It
+// returns nil between seasons.
+func viewSeason(t *i18n.Translator, cat *spec.Catalog, u *auth.User) *seasonView {
+	now := time.Now()
+	s, ok := cat.SeasonAt(now)
+	if !ok {
+		return nil
+	}
+	v := &seasonView{Season: s, EndsIn: int(s.End.Sub(now) / time.Second)}
+	if u == nil {
+		return v
+	}
+	v.Rank = leaderboard.Rank(u.Username)
+	if u.Season.ID == s.ID {
+		v.Matches = u.Season.Matches
+		v.PeakTrophies = u.Season.PeakTrophies
+		v.PeakRank = u.Season.PeakRank
+		v.Reward = prize(t, cat.SeasonReward(u.Season.PeakRank))
+	}
+	return v
+}
+
+// standingView is a player's final place in a closed season.
+type standingView struct {
+	season.Standing
+	Prize string `json:"prize,omitempty"` // Reward, described
+}
+
+// lastStanding returns where username finished in the most recent closed
+// season, if they played in it.
+func lastStanding(t *i18n.Translator, username string) (string, *standingView) {
+	closed := seasons.Closed()
+	if len(closed) == 0 {
+		return "", nil
+	}
+	a, err := seasons.Archive(closed[0].Season.ID)
+	if err != nil {
+		return "", nil
+	}
+	for _, st := range a.Standings {
+		if st.Username == username {
+			return a.Season.ID, &standingView{st, prize(t, st.Reward)}
+		}
+	}
+	return "", nil
+}
+
+// leaderboardView is the leaderboard page.
+type leaderboardView struct {
+	Season  *seasonView         `json:"season"` // nil between seasons
+	Top     []leaderboard.Entry `json:"top"`
+	Seasons []season.Archive    `json:"seasons"` // closed ones, most recent first
+}
+
+// showLeaderboard lists the players with the most trophies and the
+// season under way. Anyone can see it; a logged-in player also gets
+// their own place. It answers in JSON to clients that ask for it.
+func showLeaderboard(c *gin.Context) {
+	cat, err := spec.Current()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	var viewer *auth.User
+	if name, _ := sessions.Default(c).Get("user").(string); name != "" {
+		viewer, _ = auth.LoadUser(name)
+	}
+	v := leaderboardView{
+		Season:  viewSeason(tr(c), cat, viewer),
+		Top:     leaderboard.Top(leaderboardSize),
+		Seasons: seasons.Closed(),
+	}
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(http.StatusOK, v)
+		return
+	}
+	data := gin.H{"L": v}
+	if viewer != nil {
+		data["Viewer"] = viewer.Username
+	}
+	render(c, http.StatusOK, "leaderboard.html", data)
+}
+
+// showSeason is a closed season's final standings.
+func showSeason(c *gin.Context) {
+	a, err := seasons.Archive(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(http.StatusOK, a)
+		return
+	}
+	t := tr(c)
+	standings := make([]standingView, len(a.Standings))
+	for i, st := range a.Standings {
+		standings[i] = standingView{st, prize(t, st.Reward)}
+	}
+	viewer, _ := sessions.Default(c).Get("user").(string)
+	render(c, http.StatusOK, "season.html", gin.H{"A": a, "Standings": standings, "Viewer": viewer})
+}
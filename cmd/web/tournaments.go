@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"clashroyale/internal/tournament"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// tournaments runs every tournament; set up in main.
+var tournaments *tournament.Manager
+
+// listTournaments shows every tournament, newest first.
+func listTournaments(c *gin.Context) {
+	user := sessions.Default(c).Get("user").(string)
+	var rows []gin.H
+	for _, t := range tournaments.List() {
+		rows = append(rows, gin.H{"T": t, "Registered": t.Registered(user)})
+	}
+	render(c, http.StatusOK, "tournaments.html", gin.H{"Tournaments": rows})
+}
+
+// showTournament renders the bracket page; the bracket itself is drawn
+// from the JSON endpoint so it stays live.
+func showTournament(c *gin.Context) {
+	showTournamentPage(c, http.StatusOK, nil)
+}
+
+func showTournamentPage(c *gin.Context, status int, err error) {
+	t, terr := tournaments.Get(c.Param("id"))
+	if terr != nil {
+		writeError(c, terr)
+		return
+	}
+	user := sessions.Default(c).Get("user").(string)
+	data := gin.H{
+		"T":          t,
+		"Username":   user,
+		"Registered": t.Registered(user),
+		"Open":       t.Open(time.Now()),
+	}
+	if err != nil {
+		data["Error"] = errorMessage(c, err)
+	}
+	render(c, status, "tournament.html", data)
+}
+
+// tournamentBracket is the bracket as JSON, plus standings and the
+// viewer's game if they have one waiting.
+func tournamentBracket(c *gin.Context) {
+	t, err := tournaments.Get(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	user := sessions.Default(c).Get("user").(string)
+	yourGame := ""
+	if s := t.SeriesFor(user); s != nil && t.Status == tournament.StatusRunning {
+		yourGame = s.Current
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tournament": t,
+		"standings":  t.Standings(),
+		"yourGame":   yourGame,
+	})
+}
+
+func registerTournament(c *gin.Context) {
+	user := currentUser(c)
+	if err := user.CheckMatchmaking(time.Now()); err != nil {
+		showTournamentPage(c, http.StatusForbidden, err)
+		return
+	}
+	if err := tournaments.Register(c.Param("id"), user.Username); err != nil {
+		showTournamentPage(c, http.StatusConflict, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/tournaments/"+c.Param("id"))
+}
+
+func unregisterTournament(c *gin.Context) {
+	user := sessions.Default(c).Get("user").(string)
+	if err := tournaments.Unregister(c.Param("id"), user); err != nil {
+		showTournamentPage(c, http.StatusConflict, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/tournaments/"+c.Param("id"))
+}
+
+// Admin API
+
+// createTournament takes a tournament.Config as JSON.
+func createTournament(c *gin.Context) {
+	var cfg tournament.Config
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	t, err := tournaments.Create(cfg, currentUser(c).Username)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "create_tournament", t.ID, gin.H{"name": t.Name, "format": t.Format})
+	c.JSON(http.StatusCreated, t)
+}
+
+func startTournament(c *gin.Context) {
+	if err := tournaments.Start(c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "start_tournament", c.Param("id"), nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func cancelTournament(c *gin.Context) {
+	if err := tournaments.Cancel(c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	recordAction(c, "cancel_tournament", c.Param("id"), nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ledgerLimit reads ?limit= for ledger listings, defaulting to 100.
+func ledgerLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		badRequest(c, "invalid limit")
+		return 0, false
+	}
+	return limit, true
+}
+
+// writeLedger responds with u's balances and their recent ledger entries.
+func writeLedger(c *gin.Context, u *auth.User) {
+	limit, ok := ledgerLimit(c)
+	if !ok {
+		return
+	}
+	entries, err := wallet.History(u.Username, limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	balances := u.Wallet
+	if balances == nil {
+		balances = wallet.Balances{}
+	}
+	c.JSON(http.StatusOK, gin.H{"wallet": balances, "entries": entries})
+}
+
+// showWallet returns the current player's balances and ledger.
+func showWallet(c *gin.Context) {
+	writeLedger(c, currentUser(c))
+}
+
+// playerLedger lets staff look at any player's ledger.
+func playerLedger(c *gin.Context) {
+	u, err := auth.LoadUser(c.Param("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeLedger(c, u)
+}
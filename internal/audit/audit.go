@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Path is the audit log, one JSON entry per line. It is only ever
+// appended to.
+var Path = filepath.Join("data", "audit.log")
+
+// Entry is one recorded admin action.
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Actor   string         `json:"actor"`
+	Action  string         `json:"action"`
+	Target  string         `json:"target,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+var mu sync.Mutex
+
+// Record appends an entry for an action actor took on target.
+func Record(actor, action, target string, details map[string]any) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(Path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(Entry{
+		Time:    time.Now(),
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	})
+}
+
+// Recent returns up to limit entries, newest first.
+func Recent(limit int) ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, err := os.Open(Path)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var all []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		all = append(all, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]Entry, 0, min(limit, len(all)))
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, all[i])
+	}
+	return out, nil
+}
@@ -6,21 +6,77 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"clashroyale/internal/chest"
+	"clashroyale/internal/pass"
+	"clashroyale/internal/quest"
+	"clashroyale/internal/stats"
+	"clashroyale/internal/wallet"
+
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	Username     string         `json:"username"`
-	PasswordHash string         `json:"password_hash"`
-	Exp          int            `json:"exp"`
-	Level        int            `json:"level"`
-	TroopLevels  map[string]int `json:"troop_levels"` // Maps troop name to level
-	TowerLevels  map[string]int `json:"tower_levels"` // Maps tower name to level
+	Username     string          `json:"username"`
+	PasswordHash string          `json:"password_hash"`
+	Wallet       wallet.Balances `json:"wallet"`
+	Exp          int             `json:"exp,omitempty"` // legacy, moved into Wallet by MigrateWallets
+	Level        int             `json:"level"`
+	TroopLevels  map[string]int  `json:"troop_levels"`     // Maps troop name to level
+	TowerLevels  map[string]int  `json:"tower_levels"`     // Maps tower name to level
+	Cards        []string        `json:"cards"`            // troops unlocked, nil until the collection is first synced
+	Shards       map[string]int  `json:"shards,omitempty"` // card shards held, by troop
+	Chests       chest.Slots     `json:"chests,omitempty"`
+	Quests       quest.Board     `json:"quests,omitempty"`
+	Stats        stats.Stats     `json:"stats"`
+	Achievements []stats.Earned  `json:"achievements,omitempty"`
+	Season       stats.Season    `json:"season"`
+	Pass         pass.Pass       `json:"pass"`
+	Locale       string          `json:"locale,omitempty"` // preferred UI language, empty = browser default
+	Role         Role            `json:"role,omitempty"`   // empty = RolePlayer
+	Sanctions    []Sanction      `json:"sanctions,omitempty"`
+	Banned       bool            `json:"banned,omitempty"` // legacy, moved into Sanctions on load
+}
+
+// Role is what a user is allowed to do. Each role can do everything the
+// roles before it can.
+type Role string
+
+const (
+	RolePlayer    Role = "player"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRank = map[Role]int{RolePlayer: 0, RoleModerator: 1, RoleAdmin: 2}
+
+// ValidRole reports whether r is a known role.
+func ValidRole(r Role) bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// HasRole reports whether u has role r or a more powerful one.
+func (u *User) HasRole(r Role) bool {
+	role := u.Role
+	if role == "" {
+		role = RolePlayer
+	}
+	return roleRank[role] >= roleRank[r]
 }
 
 var dataDir = filepath.Join("data", "players")
 
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserExists      = errors.New("user already exists")
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
 func init() {
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
 		panic(fmt.Sprintf("Cannot create data directory: %v", err))
@@ -44,7 +100,7 @@ func LoadUser(username string) (*User, error) {
 	f, err := os.Open(path)
 
 	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("user %s not found", username)
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	} else if err != nil {
 		return nil, err
 	}
@@ -54,11 +110,13 @@ func LoadUser(username string) (*User, error) {
 	if err := json.NewDecoder(f).Decode(&u); err != nil {
 		return nil, err
 	}
+	u.migrateBan()
 	return &u, nil
 }
 
 // Save user
 func SaveUser(u *User) error {
+	defer prometheus.NewTimer(saveSeconds).ObserveDuration()
 	path := filepath.Join(dataDir, u.Username+".json")
 	f, err := os.Create(path)
 	if err != nil {
@@ -71,9 +129,10 @@ func SaveUser(u *User) error {
 }
 
 // reegister
-func Register(username, password string) (*User, error) {
+func Register(username, password string) (_ *User, err error) {
+	defer func() { registrations.WithLabelValues(result(err)).Inc() }()
 	if _, err := LoadUser(username); err == nil {
-		return nil, errors.New("user already exists")
+		return nil, ErrUserExists
 	}
 	hash, err := HashPassword(password)
 	if err != nil {
@@ -83,7 +142,7 @@ func Register(username, password string) (*User, error) {
 	u := &User{
 		Username:     username,
 		PasswordHash: hash,
-		Exp:          0,
+		Wallet:       make(wallet.Balances),
 		Level:        0,
 		TroopLevels:  make(map[string]int),
 		TowerLevels:  make(map[string]int),
@@ -95,13 +154,35 @@ func Register(username, password string) (*User, error) {
 }
 
 // Authen check
-func Authenticate(username, password string) (*User, error) {
+func Authenticate(username, password string) (_ *User, err error) {
+	defer func() { logins.WithLabelValues(result(err)).Inc() }()
 	u, err := LoadUser(username)
 	if err != nil {
 		return nil, err
 	}
 	if err := CheckPassword(password, u.PasswordHash); err != nil {
-		return nil, errors.New("invalid password")
+		return nil, ErrInvalidPassword
+	}
+	if err := u.CheckLogin(time.Now()); err != nil {
+		return nil, err
 	}
 	return u, nil
 }
+
+// ListUsers loads every user, sorted by username.
+func ListUsers() ([]*User, error) {
+	files, err := filepath.Glob(filepath.Join(dataDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*User, 0, len(files))
+	for _, f := range files {
+		u, err := LoadUser(strings.TrimSuffix(filepath.Base(f), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_auth_logins_total",
+		Help: "Login attempts, by result.",
+	}, []string{"result"})
+
+	registrations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_auth_registrations_total",
+		Help: "Sign-up attempts, by result.",
+	}, []string{"result"})
+
+	saveSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "clashroyale_auth_save_user_seconds",
+		Help:    "Time taken to write a user file.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 12), // 0.5ms to ~1s
+	})
+)
+
+// result labels an auth outcome for the counters above.
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrUserNotFound):
+		return "unknown_user"
+	case errors.Is(err, ErrInvalidPassword):
+		return "invalid_password"
+	case errors.Is(err, ErrUserExists):
+		return "user_exists"
+	case errors.Is(err, ErrBanned):
+		return "banned"
+	default:
+		return "error"
+	}
+}
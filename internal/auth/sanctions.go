@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+// SanctionKind is what a sanction stops the player from doing.
+type SanctionKind string
+
+const (
+	SanctionBan     SanctionKind = "ban"     // can't log in; cuts any active session
+	SanctionSuspend SanctionKind = "suspend" // can't queue or join rooms
+	SanctionMute    SanctionKind = "mute"    // can't chat
+)
+
+var (
+	ErrBanned          = errors.New("account is banned")
+	ErrSuspended       = errors.New("account is suspended from matchmaking")
+	ErrMuted           = errors.New("account is muted")
+	ErrInvalidSanction = errors.New("invalid sanction")
+)
+
+// Sanction is a penalty on an account. A zero ExpiresAt means permanent.
+// Sanctions are never deleted: lifting one sets LiftedAt so the account
+// keeps its history.
+type Sanction struct {
+	Kind      SanctionKind `json:"kind"`
+	Reason    string       `json:"reason"`
+	IssuedBy  string       `json:"issued_by"`
+	IssuedAt  time.Time    `json:"issued_at"`
+	ExpiresAt time.Time    `json:"expires_at,omitzero"`
+	LiftedAt  time.Time    `json:"lifted_at,omitzero"`
+	LiftedBy  string       `json:"lifted_by,omitempty"`
+}
+
+// Permanent reports whether the sanction never expires.
+func (s Sanction) Permanent() bool {
+	return s.ExpiresAt.IsZero()
+}
+
+// ActiveAt reports whether the sanction is in force at now.
+func (s Sanction) ActiveAt(now time.Time) bool {
+	return s.LiftedAt.IsZero() && (s.Permanent() || now.Before(s.ExpiresAt))
+}
+
+// SanctionError is returned when a sanction blocks an action. It matches
+// ErrBanned, ErrSuspended or ErrMuted with errors.Is, and carries the
+// sanction so callers can show the reason and expiry.
+type SanctionError struct {
+	Sanction Sanction
+}
+
+func (e *SanctionError) Error() string {
+	msg := e.Unwrap().Error()
+	if !e.Sanction.Permanent() {
+		msg += " until " + e.Sanction.ExpiresAt.Format(time.RFC3339)
+	}
+	if e.Sanction.Reason != "" {
+		msg += ": " + e.Sanction.Reason
+	}
+	return msg
+}
+
+func (e *SanctionError) Unwrap() error {
+	switch e.Sanction.Kind {
+	case SanctionBan:
+		return ErrBanned
+	case SanctionSuspend:
+		return ErrSuspended
+	default:
+		return ErrMuted
+	}
+}
+
+// ActiveSanctions returns the sanctions in force at now.
+func (u *User) ActiveSanctions(now time.Time) []Sanction {
+	out := []Sanction{}
+	for _, s := range u.Sanctions {
+		if s.ActiveAt(now) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// check returns a SanctionError for the longest-lasting active sanction
+// of any of kinds, or nil.
+func (u *User) check(now time.Time, kinds ...SanctionKind) error {
+	var worst *Sanction
+	for i, s := range u.Sanctions {
+		if !s.ActiveAt(now) {
+			continue
+		}
+		for _, k := range kinds {
+			if s.Kind != k {
+				continue
+			}
+			if worst == nil || s.Permanent() || (!worst.Permanent() && s.ExpiresAt.After(worst.ExpiresAt)) {
+				worst = &u.Sanctions[i]
+			}
+		}
+	}
+	if worst == nil {
+		return nil
+	}
+	return &SanctionError{*worst}
+}
+
+// CheckLogin reports whether u is banned.
+func (u *User) CheckLogin(now time.Time) error {
+	return u.check(now, SanctionBan)
+}
+
+// CheckMatchmaking reports whether u may enter a queue or room. Bans
+// count too.
+func (u *User) CheckMatchmaking(now time.Time) error {
+	if err := u.check(now, SanctionBan); err != nil {
+		return err
+	}
+	return u.check(now, SanctionSuspend)
+}
+
+// CheckChat reports whether u may send chat messages. Bans count too.
+func (u *User) CheckChat(now time.Time) error {
+	if err := u.check(now, SanctionBan); err != nil {
+		return err
+	}
+	return u.check(now, SanctionMute)
+}
+
+// Impose adds a sanction to username's account. A zero duration makes it
+// permanent.
+func Impose(username string, kind SanctionKind, reason, by string, d time.Duration) (*User, error) {
+	if kind != SanctionBan && kind != SanctionSuspend && kind != SanctionMute || d < 0 {
+		return nil, ErrInvalidSanction
+	}
+	now := time.Now()
+	s := Sanction{Kind: kind, Reason: reason, IssuedBy: by, IssuedAt: now}
+	if d > 0 {
+		s.ExpiresAt = now.Add(d)
+	}
+	u, err := Update(username, func(u *User) error {
+		u.Sanctions = append(u.Sanctions, s)
+		return nil
+	})
+	return u, err
+}
+
+// Lift ends every active sanction of kind on username's account.
+func Lift(username string, kind SanctionKind, by string) (*User, error) {
+	u, err := Update(username, func(u *User) error {
+		now := time.Now()
+		for i, s := range u.Sanctions {
+			if s.Kind == kind && s.ActiveAt(now) {
+				u.Sanctions[i].LiftedAt = now
+				u.Sanctions[i].LiftedBy = by
+			}
+		}
+		return nil
+	})
+	return u, err
+}
+
+// migrateBan turns the old permanent banned flag into a sanction.
+func (u *User) migrateBan() {
+	if !u.Banned {
+		return
+	}
+	u.Sanctions = append(u.Sanctions, Sanction{Kind: SanctionBan, Reason: "banned before sanctions", IssuedBy: "system", IssuedAt: time.Now()})
+	u.Banned = false
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+
+	"clashroyale/internal/wallet"
+)
+
+// txMu serialises wallet transactions so two of them can't both spend
+// the same gold.
+var txMu sync.Mutex
+
+// transactHooks are called with every account Transact saves.
+var transactHooks []func(*User)
+
+// OnTransact registers fn to be called with each account Transact saves,
+// e.g. to keep the leaderboard's trophy counts current. Hooks run while
+// transactions are serialised and must not change u or start another
+// one. Register them at startup, before any transaction runs.
+func OnTransact(fn func(u *User)) {
+	transactHooks = append(transactHooks, fn)
+}
+
+// Transact applies changes to username's wallet and, if edit isn't nil,
+// lets it change the rest of the account, then saves both together and
+// records what moved in the ledger under reason and ref. It returns the
+// saved account and the changes actually applied (trophy losses are
+// capped, see wallet.Balances.Apply). If a balance can't cover its
+// change, or edit fails, nothing is saved.
+func Transact(username string, changes wallet.Balances, reason, ref string, edit func(*User) error) (*User, wallet.Balances, error) {
+	txMu.Lock()
+	defer txMu.Unlock()
+
+	u, err := LoadUser(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Wallet == nil {
+		u.Wallet = make(wallet.Balances)
+	}
+	applied, err := u.Wallet.Apply(changes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if edit != nil {
+		if err := edit(u); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := SaveUser(u); err != nil {
+		return nil, nil, err
+	}
+	for _, fn := range transactHooks {
+		fn(u)
+	}
+	if err := wallet.Record(username, reason, ref, applied, u.Wallet); err != nil {
+		return u, applied, fmt.Errorf("ledger for %s: %w", username, err)
+	}
+	return u, applied, nil
+}
+
+// Update is Transact for changes that move no currency: edit changes
+// username's account and it's saved, serialised with every other
+// transaction so neither can undo the other. Nothing reaches the ledger.
+func Update(username string, edit func(*User) error) (*User, error) {
+	u, _, err := Transact(username, nil, "", "", edit)
+	return u, err
+}
+
+// MigrateWallets gives every account still on the single EXP counter a
+// wallet. The old balance was both what players spent on upgrades and
+// their progress, so it carries over as both gold and EXP. It returns
+// how many accounts were migrated.
+func MigrateWallets() (int, error) {
+	users, err := ListUsers()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, u := range users {
+		if u.Wallet != nil {
+			continue
+		}
+		old := u.Exp
+		_, _, err := Transact(u.Username, wallet.Balances{wallet.Gold: old, wallet.Exp: old}, wallet.ReasonMigration, "", func(u *User) error {
+			u.Exp = 0
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,154 @@
+package chest
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Clock is where chest timers read the time. The server always runs on
+// the wall clock; tests and tools can swap in their own.
+var Clock = time.Now
+
+var (
+	ErrSlotsFull        = errors.New("chest slots are full")
+	ErrChestNotFound    = errors.New("chest not found")
+	ErrUnlockInProgress = errors.New("another chest is already unlocking")
+	ErrNotReady         = errors.New("chest isn't unlocked yet")
+)
+
+// Chest states, see Chest.State.
+const (
+	StateLocked    = "locked"    // waiting for its unlock to be started
+	StateUnlocking = "unlocking" // timer running
+	StateReady     = "ready"     // can be opened
+)
+
+// Chest is one chest a player holds.
+type Chest struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"` // key in chests.json
+	EarnedAt  time.Time `json:"earnedAt"`
+	UnlocksAt time.Time `json:"unlocksAt,omitzero"` // zero until the unlock is started
+}
+
+// State reports where the chest is at time now.
+func (c Chest) State(now time.Time) string {
+	switch {
+	case c.UnlocksAt.IsZero():
+		return StateLocked
+	case now.Before(c.UnlocksAt):
+		return StateUnlocking
+	default:
+		return StateReady
+	}
+}
+
+// Slots are the chests a player holds, in the order they were earned.
+type Slots []Chest
+
+// Add puts a new chest of type typ in the first free slot, if any of the
+// max slots are free.
+func (s *Slots) Add(typ string, max int, now time.Time) (Chest, error) {
+	if len(*s) >= max {
+		return Chest{}, ErrSlotsFull
+	}
+	c := Chest{ID: uuid.NewString(), Type: typ, EarnedAt: now}
+	*s = append(*s, c)
+	return c, nil
+}
+
+// Drop adds the chest a win earns, picked by cat's drop weights.
+func (s *Slots) Drop(cat *spec.Catalog, now time.Time) (Chest, error) {
+	return s.Add(cat.DropChest(), cat.ChestSlots(), now)
+}
+
+// Start starts the unlock timer of chest id. Only one chest unlocks at a
+// time; a chest with no unlock time is ready straight away.
+func (s Slots) Start(id string, d time.Duration, now time.Time) (Chest, error) {
+	i := s.find(id)
+	if i < 0 {
+		return Chest{}, ErrChestNotFound
+	}
+	if s[i].State(now) != StateLocked {
+		return s[i], nil
+	}
+	for _, c := range s {
+		if d > 0 && c.State(now) == StateUnlocking {
+			return Chest{}, ErrUnlockInProgress
+		}
+	}
+	s[i].UnlocksAt = now.Add(d)
+	return s[i], nil
+}
+
+// Take removes chest id from the slots once it's ready to open.
+func (s *Slots) Take(id string, now time.Time) (Chest, error) {
+	i := s.find(id)
+	if i < 0 {
+		return Chest{}, ErrChestNotFound
+	}
+	c := (*s)[i]
+	if c.State(now) != StateReady {
+		return Chest{}, ErrNotReady
+	}
+	*s = append((*s)[:i], (*s)[i+1:]...)
+	return c, nil
+}
+
+// Get returns chest id.
+func (s Slots) Get(id string) (Chest, bool) {
+	if i := s.find(id); i >= 0 {
+		return s[i], true
+	}
+	return Chest{}, false
+}
+
+func (s Slots) find(id string) int {
+	for i, c := range s {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Loot is what a chest held.
+type Loot struct {
+	Wallet wallet.Balances `json:"wallet"`
+	Shards map[string]int  `json:"shards,omitempty"` // by troop
+}
+
+// Roll opens a chest of type ch. Each shard drop goes to one troop of its
+// rarity, preferring locked troops (owns reports false) that need shards
+// to unlock.
+func Roll(cat *spec.Catalog, ch spec.Chest, owns func(troop string) bool) Loot {
+	loot := Loot{
+		Wallet: wallet.Balances{wallet.Gold: ch.Gold.Roll(), wallet.Exp: ch.Exp.Roll()},
+		Shards: make(map[string]int),
+	}
+	for _, sd := range ch.Shards {
+		all := cat.TroopsOfRarity(sd.Rarity)
+		var wanted []string
+		for _, name := range all {
+			if req, ok := cat.Unlock(name); ok && req.Shards > 0 && !owns(name) {
+				wanted = append(wanted, name)
+			}
+		}
+		if len(wanted) == 0 {
+			wanted = all
+		}
+		if len(wanted) == 0 {
+			continue
+		}
+		if n := sd.Roll(); n > 0 {
+			loot.Shards[wanted[rand.Intn(len(wanted))]] += n
+		}
+	}
+	return loot
+}
@@ -0,0 +1,181 @@
+package chest
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestState(t *testing.T) {
+	unlocking := Chest{UnlocksAt: t0.Add(time.Hour)}
+	tests := []struct {
+		name string
+		c    Chest
+		now  time.Time
+		want string
+	}{
+		{"not started", Chest{}, t0, StateLocked},
+		{"timer running", unlocking, t0, StateUnlocking},
+		{"a second to go", unlocking, t0.Add(time.Hour - time.Second), StateUnlocking},
+		{"timer just ran out", unlocking, t0.Add(time.Hour), StateReady},
+		{"long since ready", unlocking, t0.Add(48 * time.Hour), StateReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.State(tt.now); got != tt.want {
+				t.Errorf("State() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		slots   Slots
+		id      string
+		d       time.Duration
+		want    time.Time // UnlocksAt of id afterwards
+		wantErr error
+	}{
+		{
+			name:  "starts the timer",
+			slots: Slots{{ID: "a"}},
+			id:    "a",
+			d:     3 * time.Hour,
+			want:  t0.Add(3 * time.Hour),
+		},
+		{
+			name:  "no unlock time is ready straight away",
+			slots: Slots{{ID: "a"}},
+			id:    "a",
+			want:  t0,
+		},
+		{
+			name:    "one chest at a time",
+			slots:   Slots{{ID: "a", UnlocksAt: t0.Add(time.Hour)}, {ID: "b"}},
+			id:      "b",
+			d:       3 * time.Hour,
+			wantErr: ErrUnlockInProgress,
+		},
+		{
+			name:  "the next one can start once the first is ready",
+			slots: Slots{{ID: "a", UnlocksAt: t0}, {ID: "b"}},
+			id:    "b",
+			d:     3 * time.Hour,
+			want:  t0.Add(3 * time.Hour),
+		},
+		{
+			name:  "a chest with no unlock time skips the line",
+			slots: Slots{{ID: "a", UnlocksAt: t0.Add(time.Hour)}, {ID: "b"}},
+			id:    "b",
+			want:  t0,
+		},
+		{
+			name:  "starting twice keeps the first timer",
+			slots: Slots{{ID: "a", UnlocksAt: t0.Add(time.Hour)}},
+			id:    "a",
+			d:     3 * time.Hour,
+			want:  t0.Add(time.Hour),
+		},
+		{
+			name:    "unknown chest",
+			slots:   Slots{{ID: "a"}},
+			id:      "b",
+			d:       time.Hour,
+			wantErr: ErrChestNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.slots.Start(tt.id, tt.d, t0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Start() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !c.UnlocksAt.Equal(tt.want) {
+				t.Errorf("UnlocksAt = %v, want %v", c.UnlocksAt, tt.want)
+			}
+			if got, _ := tt.slots.Get(tt.id); !got.UnlocksAt.Equal(tt.want) {
+				t.Errorf("saved UnlocksAt = %v, want %v", got.UnlocksAt, tt.want)
+			}
+		})
+	}
+}
+
+func TestTake(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Chest
+		now     time.Time
+		wantErr error
+	}{
+		{"locked", Chest{ID: "a"}, t0, ErrNotReady},
+		{"still unlocking", Chest{ID: "a", UnlocksAt: t0.Add(time.Minute)}, t0, ErrNotReady},
+		{"ready", Chest{ID: "a", UnlocksAt: t0}, t0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Slots{tt.c, {ID: "b"}}
+			_, err := s.Take("a", tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Take() error = %v, want %v", err, tt.wantErr)
+			}
+			_, held := s.Get("a")
+			if held != (err != nil) {
+				t.Errorf("chest still held = %v after Take() error %v", held, err)
+			}
+			if len(s) == 0 || s[len(s)-1].ID != "b" {
+				t.Errorf("other chest lost: %v", s)
+			}
+		})
+	}
+
+	var s Slots
+	if _, err := s.Take("a", t0); !errors.Is(err, ErrChestNotFound) {
+		t.Errorf("Take() on empty slots error = %v, want %v", err, ErrChestNotFound)
+	}
+}
+
+// TestUnlockOnClock walks a chest from earned to opened on a swapped-in
+// clock, the way the handlers read the time.
+func TestUnlockOnClock(t *testing.T) {
+	now := t0
+	defer func(old func() time.Time) { Clock = old }(Clock)
+	Clock = func() time.Time { return now }
+
+	var s Slots
+	c, err := s.Add("silver", 4, Clock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start(c.ID, 3*time.Hour, Clock()); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(3*time.Hour - time.Second)
+	if _, err := s.Take(c.ID, Clock()); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("Take() a second early error = %v, want %v", err, ErrNotReady)
+	}
+	now = now.Add(time.Second)
+	if _, err := s.Take(c.ID, Clock()); err != nil {
+		t.Fatalf("Take() once ready: %v", err)
+	}
+	if len(s) != 0 {
+		t.Errorf("slots = %v, want empty", s)
+	}
+}
+
+func TestAddFull(t *testing.T) {
+	s := Slots{{ID: "a"}, {ID: "b"}}
+	if _, err := s.Add("silver", 2, t0); !errors.Is(err, ErrSlotsFull) {
+		t.Errorf("Add() error = %v, want %v", err, ErrSlotsFull)
+	}
+	if len(s) != 2 {
+		t.Errorf("len(slots) = %d, want 2", len(s))
+	}
+}
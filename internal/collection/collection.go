@@ -0,0 +1,110 @@
+package collection
+
+import (
+	"errors"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+)
+
+// ErrLocked is reported when a player uses a card they haven't unlocked.
+var ErrLocked = errors.New("card not unlocked yet")
+
+// Card is one troop as seen from a player's collection.
+type Card struct {
+	Name     string       `json:"name"`
+	Rarity   string       `json:"rarity,omitempty"`
+	Owned    bool         `json:"owned"`
+	Unlock   *spec.Unlock `json:"unlock,omitempty"` // nil for starter cards
+	Progress spec.Unlock  `json:"progress"`         // where the player stands on each requirement
+}
+
+// Met reports whether u meets every part of req.
+func Met(u *auth.User, req spec.Unlock, name string) bool {
+	p := progress(u, name)
+	return p.Level >= req.Level && p.Trophies >= req.Trophies && p.Shards >= req.Shards
+}
+
+func progress(u *auth.User, name string) spec.Unlock {
+	return spec.Unlock{Level: u.Level, Trophies: u.Wallet[wallet.Trophies], Shards: u.Shards[name]}
+}
+
+// Sync adds every card u now qualifies for to its collection, spending
+// the shards they cost, and returns their names in catalog order. Cards
+// stay owned even if the player later drops below a requirement.
+//
+// An account that has never been synced gets the starter cards, plus any
+// troop it has already upgraded past level 1: those were playable before
+// unlocks existed. Level 1 doesn't count, every troop was saved at it.
+func Sync(cat *spec.Catalog, u *auth.User) []string {
+	owned := make(map[string]bool, len(u.Cards))
+	for _, name := range u.Cards {
+		owned[name] = true
+	}
+	if u.Cards == nil {
+		u.Cards = []string{}
+		for _, t := range cat.Troops() {
+			if _, locked := cat.Unlock(t.Name); !locked || u.TroopLevels[t.Name] > 1 {
+				u.Cards = append(u.Cards, t.Name)
+				owned[t.Name] = true
+			}
+		}
+	}
+
+	var unlocked []string
+	for _, t := range cat.Troops() {
+		req, ok := cat.Unlock(t.Name)
+		if owned[t.Name] {
+			continue
+		}
+		if !ok {
+			// a troop added to the catalog as a starter after this account was made
+			u.Cards = append(u.Cards, t.Name)
+			continue
+		}
+		if !Met(u, req, t.Name) {
+			continue
+		}
+		if req.Shards > 0 {
+			u.Shards[t.Name] -= req.Shards
+			if u.Shards[t.Name] == 0 {
+				delete(u.Shards, t.Name)
+			}
+		}
+		u.Cards = append(u.Cards, t.Name)
+		unlocked = append(unlocked, t.Name)
+	}
+	return unlocked
+}
+
+// Refresh syncs username's collection and saves it, returning the
+// account and any cards it unlocked.
+func Refresh(cat *spec.Catalog, username string) (*auth.User, []string, error) {
+	var unlocked []string
+	u, err := auth.Update(username, func(u *auth.User) error {
+		unlocked = Sync(cat, u)
+		return nil
+	})
+	return u, unlocked, err
+}
+
+// List shows every troop in cat from u's point of view, in catalog order.
+func List(cat *spec.Catalog, u *auth.User) []Card {
+	owned := make(map[string]bool, len(u.Cards))
+	for _, name := range u.Cards {
+		owned[name] = true
+	}
+	var out []Card
+	for _, t := range cat.Troops() {
+		c := Card{Name: t.Name, Owned: owned[t.Name], Progress: progress(u, t.Name)}
+		if cv, ok := cat.Curve(spec.KindTroop, t.Name); ok {
+			c.Rarity = cv.Rarity
+		}
+		if req, ok := cat.Unlock(t.Name); ok {
+			c.Unlock = &req
+		}
+		out = append(out, c)
+	}
+	return out
+}
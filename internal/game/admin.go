@@ -0,0 +1,74 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+// GameSummary is a live game as seen from the admin console.
+type GameSummary struct {
+	ID       string    `json:"id"`
+	Players  []string  `json:"players"`
+	Sides    []int     `json:"sides"`
+	Mode     string    `json:"mode"`
+	Started  bool      `json:"started"` // false until a player opens the game page
+	StartAt  time.Time `json:"startTime"`
+	TimeLeft int       `json:"timeLeft"` // seconds
+	TowerHP  [2]int    `json:"towerHP"`  // total per side
+	Offline  []string  `json:"offline"`  // players currently disconnected
+}
+
+// LiveGames lists every game the lobby has set up and nobody has
+// finished yet, oldest first.
+func LiveGames() []GameSummary {
+	out := []GameSummary{}
+	for id, match := range lobbyMgr.Matches() {
+		sum := GameSummary{ID: id, Players: match.Players, Sides: match.Sides, Mode: match.Options.Mode, Offline: []string{}}
+
+		mgr.mu.Lock()
+		gs, ok := mgr.games[id]
+		mgr.mu.Unlock()
+		if ok {
+			gs.mu.Lock()
+			sum.Started = true
+			sum.StartAt = gs.StartTime
+			sum.TimeLeft = max(int((gs.Duration - time.Since(gs.StartTime)).Seconds()), 0)
+			for side := range gs.Towers {
+				for _, tw := range gs.Towers[side] {
+					sum.TowerHP[side] += max(tw.HP, 0)
+				}
+			}
+			for _, p := range gs.Players {
+				if gs.Disconnected[p.Username] {
+					sum.Offline = append(sum.Offline, p.Username)
+				}
+			}
+			gs.mu.Unlock()
+		}
+		out = append(out, sum)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartAt.Before(out[j].StartAt) })
+	return out
+}
+
+// ForceFinish ends a game on a moderator's say-so, deciding the winner on
+// towers as if time had run out. Games nobody has opened yet are created
+// first so their players still get a result.
+func ForceFinish(gameID string) error {
+	gs, err := GetOrCreate(gameID)
+	if err != nil {
+		return err
+	}
+
+	gs.mu.Lock()
+	if gs.IsFinished {
+		gs.mu.Unlock()
+		return ErrGameFinished
+	}
+	err = gs.finish(ReasonAdmin)
+	r := gs.result()
+	gs.mu.Unlock()
+
+	gs.afterFinish(r)
+	return err
+}
@@ -1,19 +1,79 @@
 package game
 
 import (
+	"clashroyale/internal/i18n"
 	"clashroyale/internal/model"
 	"time"
 )
 
 // PublicState is what you'll serialize over JSON to the browser.
 type PublicState struct {
-	YourMana  int            `json:"yourMana"`
-	YourHand  []TroopView    `json:"yourHand"`
-	Towers    [2][]TowerView `json:"towers"`   // [you, opponent]
-	TimeLeft  int            `json:"timeLeft"` // seconds
-	Finished  bool           `json:"finished"`
-	Winner    string         `json:"winner"`
-	BattleLog []string       `json:"battleLog"`
+	YourMana int            `json:"yourMana"`
+	YourHand []TroopView    `json:"yourHand"`
+	Towers   [2][]TowerView `json:"towers"`   // [you, opponent]
+	TimeLeft int            `json:"timeLeft"` // seconds
+
+	// Teammates is empty in 1v1
+	Teammates []string `json:"teammates"`
+	Opponents []string `json:"opponents"`
+
+	Finished bool           `json:"finished"`
+	Winner   string         `json:"winner"`
+	Reason   FinishReason   `json:"reason,omitempty"`
+	Results  []PlayerResult `json:"results,omitempty"` // everyone's performance and rewards, once finished
+	Events   []EventView    `json:"events"`            // events after the requested cursor
+	Cursor   int            `json:"cursor"`            // pass back as ?after= on the next poll
+
+	// Opponent connection: StatusConnected or StatusDisconnected, and the
+	// seconds they have left to reconnect before forfeiting
+	OpponentStatus    string `json:"opponentStatus"`
+	OpponentGraceLeft int    `json:"opponentGraceLeft"`
+
+	// Rematch: whether you and anyone else have offered, and the new
+	// game's ID once everyone has
+	YouOfferedRematch      bool   `json:"youOfferedRematch"`
+	OpponentOfferedRematch bool   `json:"opponentOfferedRematch"`
+	RematchID              string `json:"rematchID"`
+
+	// Resync is set when the events are the full history rather than a
+	// delta, e.g. after a reconnect. Clients should clear their log.
+	Resync bool `json:"resync"`
+}
+
+// PlayerResult is one player's line on the results screen.
+type PlayerResult struct {
+	Username string  `json:"username"`
+	Ally     bool    `json:"ally"` // on the viewer's side
+	Damage   int     `json:"damage"`
+	Tally    Tally   `json:"tally"`
+	Reward   *Reward `json:"reward"`
+}
+
+// results lists every player's performance for a viewer on side, their
+// side first. It's nil until the game is finished. Caller must hold gs.mu.
+func (gs *GameState) results(side int) []PlayerResult {
+	if !gs.IsFinished {
+		return nil
+	}
+	out := make([]PlayerResult, 0, len(gs.Players))
+	for _, s := range []int{side, 1 - side} {
+		for _, p := range gs.Members(s) {
+			out = append(out, PlayerResult{
+				Username: p.Username,
+				Ally:     s == side,
+				Damage:   gs.Damage[p.Username],
+				Tally:    *gs.tally(p.Username),
+				Reward:   gs.Rewards[p.Username],
+			})
+		}
+	}
+	return out
+}
+
+// EventView is an Event plus its rendered battle log line.
+type EventView struct {
+	Event
+	Text string `json:"text"`
 }
 
 // TroopView and TowerView are simplified versions of your full models:
@@ -29,11 +89,17 @@ type TowerView struct {
 	HP   int    `json:"hp"`
 }
 
-// Snapshot builds a PublicState for the given user
-func (gs *GameState) Snapshot(user string) PublicState {
-	// determine which index is "you" and "them"
+// Snapshot builds a PublicState for the given user, including only the
+// battle events after the given cursor, rendered in tr's locale
+func (gs *GameState) Snapshot(user string, after int, tr *i18n.Translator) PublicState {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.RegenMana(user)
+
+	// determine which index is "you", and which sides are "us" and "them"
 	idx := gs.PlayerIndex(user)
-	opp := 1 - idx
+	side := gs.Sides[idx]
+	opp := 1 - side
 
 	// time left in seconds
 	elapsed := time.Since(gs.StartTime)
@@ -57,13 +123,55 @@ func (gs *GameState) Snapshot(user string) PublicState {
 		return vs
 	}
 
+	events := gs.eventsSince(after)
+	views := make([]EventView, len(events))
+	cursor := after
+	for i, e := range events {
+		views[i] = EventView{e, RenderEvent(tr, e)}
+		cursor = e.Seq
+	}
+
+	// an opponent side counts as disconnected if any of its players is;
+	// report whoever is closest to forfeiting
+	oppStatus, graceLeft := StatusConnected, 0
+	oppOffered := false
+	var teammates, opponents []string
+	for i, p := range gs.Players {
+		switch {
+		case gs.Sides[i] == opp:
+			opponents = append(opponents, p.Username)
+			if st, left := gs.connectionStatus(p.Username, time.Now()); st == StatusDisconnected &&
+				(oppStatus == StatusConnected || left < graceLeft) {
+				oppStatus, graceLeft = st, left
+			}
+		case p.Username != user:
+			teammates = append(teammates, p.Username)
+		}
+		if p.Username != user && gs.RematchOffers[p.Username] {
+			oppOffered = true
+		}
+	}
+
 	return PublicState{
 		YourMana:  gs.Mana[user],
 		YourHand:  yourHand,
-		Towers:    [2][]TowerView{toViews(gs.Towers[idx]), toViews(gs.Towers[opp])},
+		Towers:    [2][]TowerView{toViews(gs.Towers[side]), toViews(gs.Towers[opp])},
+		Teammates: teammates,
+		Opponents: opponents,
 		TimeLeft:  remaining,
 		Finished:  gs.IsFinished,
 		Winner:    gs.Winner,
-		BattleLog: gs.GetBattleLog(),
+		Reason:    gs.Reason,
+		Results:   gs.results(side),
+		Events:    views,
+		Cursor:    cursor,
+
+		OpponentStatus:         oppStatus,
+		OpponentGraceLeft:      graceLeft,
+		YouOfferedRematch:      gs.RematchOffers[user],
+		OpponentOfferedRematch: oppOffered,
+		RematchID:              gs.RematchID,
+
+		Resync: after == 0,
 	}
 }
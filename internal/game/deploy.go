@@ -0,0 +1,236 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"clashroyale/internal/model"
+)
+
+// A deploy runs as a three-stage pipeline, all under gs.mu:
+//
+//  1. validateDeploy checks the command against the current state and
+//     returns a deployPlan. It never mutates the game, so a rejected
+//     deploy leaves mana, hand and towers exactly as they were.
+//  2. applyDeploy commits the plan: pays mana, swaps the card for a new
+//     one and resolves combat. It can't fail.
+//  3. emit appends the events the apply stage produced to the battle stream.
+
+// DeployCommand is a player's request to play a troop from their hand.
+type DeployCommand struct {
+	Username string
+	Troop    string
+}
+
+// deployPlan is a validated DeployCommand, ready to apply.
+type deployPlan struct {
+	cmd      DeployCommand
+	player   int          // index of the deploying player
+	enemy    int          // side being attacked
+	handSlot int          // position of the card in the player's hand
+	troop    *model.Troop // the card being played
+	target   *model.Tower // first enemy tower still standing
+	now      time.Time
+}
+
+// deployResult is what applying a plan produced.
+type deployResult struct {
+	events []Event
+	won    bool // the deploying player destroyed every enemy tower
+}
+
+// Deploy plays troopName from username's hand against the enemy towers.
+func (gs *GameState) Deploy(username, troopName string) error {
+	cmd := DeployCommand{Username: username, Troop: troopName}
+
+	gs.mu.Lock()
+	plan, err := gs.validateDeploy(cmd, time.Now())
+	if err != nil {
+		gs.mu.Unlock()
+		deployRejections.WithLabelValues(rejectReason(err)).Inc()
+		if errors.Is(err, ErrTimeUp) {
+			if ferr := gs.FinishGame(ReasonTimeUp); ferr != nil {
+				return ferr
+			}
+		}
+		return err
+	}
+
+	res := gs.applyDeploy(plan)
+	gs.emit(res.events...)
+	deploys.Inc()
+
+	if res.won {
+		gs.setWinner(gs.Side(username))
+		err := gs.finish(ReasonTowersDestroyed)
+		r := gs.result()
+		gs.mu.Unlock()
+
+		gs.afterFinish(r)
+		return err
+	}
+	timeUp := time.Since(gs.StartTime) > gs.Duration
+	gs.mu.Unlock()
+
+	if timeUp {
+		return gs.FinishGame(ReasonTimeUp)
+	}
+	return nil
+}
+
+// validateDeploy checks membership, phase, hand, mana and target, in that
+// order. Caller must hold gs.mu.
+func (gs *GameState) validateDeploy(cmd DeployCommand, now time.Time) (*deployPlan, error) {
+	if !gs.HasPlayer(cmd.Username) {
+		return nil, ErrNotInGame
+	}
+	if gs.IsFinished {
+		return nil, ErrGameFinished
+	}
+	if now.Sub(gs.StartTime) > gs.Duration {
+		return nil, ErrTimeUp
+	}
+
+	idx := gs.PlayerIndex(cmd.Username)
+	slot := -1
+	for i, t := range gs.Hands[idx] {
+		if t.Name == cmd.Troop {
+			slot = i
+			break
+		}
+	}
+	if slot < 0 {
+		return nil, ErrTroopNotInHand
+	}
+	troop := gs.Hands[idx][slot]
+
+	if gs.manaAt(cmd.Username, now) < troop.Cost {
+		return nil, ErrNotEnoughMana
+	}
+
+	enemy := 1 - gs.Sides[idx]
+	target := findTarget(gs.Towers[enemy])
+	if target == nil {
+		// every enemy tower is down, so the game is already won
+		return nil, ErrGameFinished
+	}
+	return &deployPlan{
+		cmd:      cmd,
+		player:   idx,
+		enemy:    enemy,
+		handSlot: slot,
+		troop:    troop,
+		target:   target,
+		now:      now,
+	}, nil
+}
+
+// findTarget picks the first alive Guard Tower, then the King Tower.
+func findTarget(towers []*model.Tower) *model.Tower {
+	for _, name := range []string{"Guard Tower", "King Tower"} {
+		for _, tw := range towers {
+			if tw.HP > 0 && tw.Name == name {
+				return tw
+			}
+		}
+	}
+	return nil
+}
+
+// applyDeploy commits a validated plan. Caller must hold gs.mu.
+func (gs *GameState) applyDeploy(p *deployPlan) deployResult {
+	var res deployResult
+	user := p.cmd.Username
+
+	// Pay for the card, then replace it in hand
+	gs.Mana[user] = gs.manaAt(user, p.now) - p.troop.Cost
+	gs.LastRegen[user] = p.now
+	hand := gs.Hands[p.player]
+	gs.Hands[p.player] = append(hand[:p.handSlot:p.handSlot], hand[p.handSlot+1:]...)
+	gs.drawNewTroop(p.player)
+	res.events = append(res.events, Event{Type: EventDeploy, Player: user, Troop: p.troop.Name})
+
+	res.events = append(res.events, gs.resolveCombat(user, p.troop, p.target)...)
+	for _, e := range res.events {
+		if !e.Counter {
+			gs.Damage[user] += e.Damage
+		}
+	}
+	gs.recordDeploy(user, p.troop, p.target, res.events)
+	res.won = allDestroyed(gs.Towers[p.enemy])
+	return res
+}
+
+// allDestroyed reports whether every tower is down.
+func allDestroyed(towers []*model.Tower) bool {
+	for _, tw := range towers {
+		if tw.HP > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveCombat fights troop against target until one of them falls and
+// returns the events it produced.
+func (gs *GameState) resolveCombat(username string, troop *model.Troop, target *model.Tower) []Event {
+	var events []Event
+	for troop.HP > 0 && target.HP > 0 {
+		// Troop attacks tower
+		atk := troop.ATK
+		isCrit := rand.Float64() < gs.CritChance
+		if isCrit {
+			atk = int(float64(atk) * 1.2)
+		}
+		dmg := max(atk-target.DEF, 0)
+		target.HP -= dmg
+		events = append(events, Event{
+			Type:   attackType(isCrit),
+			Player: username,
+			Troop:  troop.Name,
+			Tower:  target.Name,
+			Damage: dmg,
+			HP:     target.HP,
+		})
+
+		// Check if tower is destroyed
+		if target.HP <= 0 {
+			events = append(events, Event{Type: EventTowerDestroyed, Player: username, Troop: troop.Name, Tower: target.Name})
+			break
+		}
+
+		// Tower counter-attacks
+		towerAtk := target.ATK
+		towerIsCrit := rand.Float64() < target.Crit
+		if towerIsCrit {
+			towerAtk = int(float64(towerAtk) * 1.2)
+		}
+		towerDmg := max(towerAtk-troop.DEF, 0)
+		troop.HP -= towerDmg
+		events = append(events, Event{
+			Type:    attackType(towerIsCrit),
+			Player:  username,
+			Troop:   troop.Name,
+			Tower:   target.Name,
+			Counter: true,
+			Damage:  towerDmg,
+			HP:      troop.HP,
+		})
+
+		// Check if troop is destroyed
+		if troop.HP <= 0 {
+			events = append(events, Event{Type: EventTroopDefeated, Player: username, Troop: troop.Name, Tower: target.Name})
+			break
+		}
+	}
+	return events
+}
+
+// attackType picks EventCrit for critical hits and EventAttack otherwise.
+func attackType(crit bool) EventType {
+	if crit {
+		return EventCrit
+	}
+	return EventAttack
+}
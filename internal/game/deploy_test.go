@@ -0,0 +1,206 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"clashroyale/internal/model"
+	"clashroyale/internal/spec"
+)
+
+// newTestGame starts a 1v1 between a (side 0) and b (side 1) on the
+// repo's specs, at now, with no critical hits so combat is predictable.
+// a holds one of every troop and 5 mana.
+func newTestGame(t *testing.T, now time.Time) *GameState {
+	t.Helper()
+	cat, err := spec.Load("../../specs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := &GameState{
+		ID:        "test",
+		Sides:     []int{0, 1},
+		Hands:     make([][]*model.Troop, 2),
+		Damage:    make(map[string]int),
+		Tallies:   make(map[string]*Tally),
+		QuestSeq:  make(map[string]int),
+		Mana:      map[string]int{"a": 5, "b": 5},
+		LastRegen: map[string]time.Time{"a": now, "b": now},
+		StartTime: now,
+		Duration:  3 * time.Minute,
+		Catalog:   cat,
+	}
+	for _, name := range []string{"a", "b"} {
+		gs.Players = append(gs.Players, &model.Player{Username: name, TroopLevels: map[string]int{}})
+	}
+	for side := range gs.Towers {
+		gs.Towers[side] = cat.Towers()
+		for _, tw := range gs.Towers[side] {
+			tw.Crit = 0
+		}
+	}
+	gs.Hands[0] = cat.Troops()
+	gs.Hands[1] = cat.Troops()
+	return gs
+}
+
+func TestValidateDeploy(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		setup   func(gs *GameState)
+		cmd     DeployCommand
+		wantErr error
+	}{
+		{
+			name: "valid",
+			cmd:  DeployCommand{Username: "a", Troop: "Archer"},
+		},
+		{
+			name:    "not a player",
+			cmd:     DeployCommand{Username: "c", Troop: "Archer"},
+			wantErr: ErrNotInGame,
+		},
+		{
+			name:    "game over",
+			setup:   func(gs *GameState) { gs.IsFinished = true },
+			cmd:     DeployCommand{Username: "a", Troop: "Archer"},
+			wantErr: ErrGameFinished,
+		},
+		{
+			name: "no towers left",
+			setup: func(gs *GameState) {
+				for _, tw := range gs.Towers[1] {
+					tw.HP = 0
+				}
+			},
+			cmd:     DeployCommand{Username: "a", Troop: "Archer"},
+			wantErr: ErrGameFinished,
+		},
+		{
+			name:    "time up",
+			setup:   func(gs *GameState) { gs.StartTime = now.Add(-gs.Duration - time.Second) },
+			cmd:     DeployCommand{Username: "a", Troop: "Archer"},
+			wantErr: ErrTimeUp,
+		},
+		{
+			name:    "not in hand",
+			setup:   func(gs *GameState) { gs.Hands[0] = gs.Hands[0][:1] },
+			cmd:     DeployCommand{Username: "a", Troop: "Archer"},
+			wantErr: ErrTroopNotInHand,
+		},
+		{
+			name:    "not enough mana",
+			setup:   func(gs *GameState) { gs.Mana["a"] = 1 },
+			cmd:     DeployCommand{Username: "a", Troop: "Archer"},
+			wantErr: ErrNotEnoughMana,
+		},
+		{
+			name:  "mana regenerated since the last deploy counts",
+			setup: func(gs *GameState) { gs.Mana["a"], gs.LastRegen["a"] = 0, now.Add(-2*time.Second) },
+			cmd:   DeployCommand{Username: "a", Troop: "Archer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := newTestGame(t, now)
+			if tt.setup != nil {
+				tt.setup(gs)
+			}
+			mana, hand := gs.Mana["a"], slices.Clone(gs.Hands[0])
+
+			plan, err := gs.validateDeploy(tt.cmd, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateDeploy() error = %v, want %v", err, tt.wantErr)
+			}
+			if gs.Mana["a"] != mana || !slices.Equal(gs.Hands[0], hand) {
+				t.Errorf("validateDeploy() changed the game: mana %d -> %d, hand %v -> %v", mana, gs.Mana["a"], hand, gs.Hands[0])
+			}
+			if err != nil {
+				return
+			}
+			if got := gs.Hands[0][plan.handSlot].Name; got != tt.cmd.Troop {
+				t.Errorf("plan plays %q, want %q", got, tt.cmd.Troop)
+			}
+			if plan.enemy != 1 {
+				t.Errorf("plan attacks side %d, want 1", plan.enemy)
+			}
+			if plan.target.Name != "Guard Tower" {
+				t.Errorf("plan targets %v, want the Guard Tower first", plan.target)
+			}
+		})
+	}
+}
+
+func TestApplyDeploy(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		setup     func(gs *GameState)
+		troop     string
+		want      []EventType
+		wantWon   bool
+		wantDealt int // damage a has dealt afterwards
+	}{
+		{
+			// Swordman hits the Guard Tower for 1 and falls to its counter
+			name:      "troop falls",
+			troop:     "Swordman",
+			want:      []EventType{EventDeploy, EventAttack, EventAttack, EventTroopDefeated},
+			wantDealt: 1,
+		},
+		{
+			name: "last tower falls",
+			setup: func(gs *GameState) {
+				for _, tw := range gs.Towers[1] {
+					tw.HP = 0
+					if tw.Name == "Guard Tower" {
+						tw.HP = 1
+					}
+				}
+			},
+			// the hit counts in full even past 0 HP
+			troop:     "Mage",
+			want:      []EventType{EventDeploy, EventAttack, EventTowerDestroyed},
+			wantWon:   true,
+			wantDealt: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := newTestGame(t, now)
+			if tt.setup != nil {
+				tt.setup(gs)
+			}
+			plan, err := gs.validateDeploy(DeployCommand{Username: "a", Troop: tt.troop}, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hand, cost := len(gs.Hands[0]), plan.troop.Cost
+
+			res := gs.applyDeploy(plan)
+
+			var got []EventType
+			for _, e := range res.events {
+				got = append(got, e.Type)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+			if res.won != tt.wantWon {
+				t.Errorf("won = %v, want %v", res.won, tt.wantWon)
+			}
+			if gs.Mana["a"] != 5-cost {
+				t.Errorf("mana = %d, want %d", gs.Mana["a"], 5-cost)
+			}
+			if len(gs.Hands[0]) != hand {
+				t.Errorf("hand has %d cards, want %d", len(gs.Hands[0]), hand)
+			}
+			if gs.Damage["a"] != tt.wantDealt {
+				t.Errorf("damage dealt = %d, want %d", gs.Damage["a"], tt.wantDealt)
+			}
+		})
+	}
+}
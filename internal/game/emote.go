@@ -0,0 +1,50 @@
+package game
+
+import (
+	"slices"
+	"time"
+
+	"clashroyale/internal/auth"
+)
+
+// Emotes are the reactions players can send during a match. There's no
+// free text, so there's nothing to filter.
+var Emotes = []string{"thumbs_up", "laugh", "cry", "angry", "good_game"}
+
+// EmoteCooldown is how long a player must wait between emotes.
+const EmoteCooldown = 3 * time.Second
+
+// Emote puts user's emote in the battle stream for everyone in the match
+// to see. Muted and banned players can't send one.
+func (gs *GameState) Emote(user, emote string) error {
+	if !gs.HasPlayer(user) {
+		return ErrNotInGame
+	}
+	if !slices.Contains(Emotes, emote) {
+		return ErrUnknownEmote
+	}
+	u, err := auth.LoadUser(user)
+	if err != nil {
+		return err
+	}
+	if err := u.CheckChat(time.Now()); err != nil {
+		return err
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.IsFinished {
+		return ErrGameFinished
+	}
+	for i := len(gs.Events) - 1; i >= 0; i-- {
+		e := gs.Events[i]
+		if time.Since(e.Time) >= EmoteCooldown {
+			break
+		}
+		if e.Type == EventEmote && e.Player == user {
+			return ErrEmoteTooSoon
+		}
+	}
+	gs.emit(Event{Type: EventEmote, Player: user, Kind: emote})
+	return nil
+}
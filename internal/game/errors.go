@@ -0,0 +1,23 @@
+package game
+
+import (
+	"errors"
+
+	"clashroyale/internal/auth"
+)
+
+// Domain errors returned by the game API. Handlers match on these with
+// errors.Is to pick a status code, so wrap them rather than replacing them.
+var (
+	ErrUserNotFound    = auth.ErrUserNotFound
+	ErrGameNotFound    = errors.New("game not found")
+	ErrNotInGame       = errors.New("you are not a player in this game")
+	ErrGameFinished    = errors.New("game already finished")
+	ErrTimeUp          = errors.New("game time is up")
+	ErrGameNotFinished = errors.New("game is still in progress")
+	ErrRematchExpired  = errors.New("rematch offer has expired")
+	ErrNotEnoughMana   = errors.New("not enough mana")
+	ErrTroopNotInHand  = errors.New("troop not found in hand")
+	ErrUnknownEmote    = errors.New("unknown emote")
+	ErrEmoteTooSoon    = errors.New("emote sent too soon")
+)
@@ -0,0 +1,87 @@
+package game
+
+import "time"
+
+// EventType identifies what happened in a battle event.
+type EventType string
+
+const (
+	EventDeploy         EventType = "deploy"          // a player played a troop
+	EventAttack         EventType = "attack"          // a hit, possibly for 0 damage
+	EventCrit           EventType = "crit"            // a critical hit
+	EventTowerDestroyed EventType = "tower_destroyed" // a tower reached 0 HP
+	EventTroopDefeated  EventType = "troop_defeated"  // a deployed troop reached 0 HP
+	EventRandomEvent    EventType = "random_event"    // one of the 30s global events fired
+	EventGameOver       EventType = "game_over"       // the match ended
+	EventDisconnected   EventType = "disconnected"    // a player stopped polling
+	EventReconnected    EventType = "reconnected"     // a disconnected player came back
+	EventEmote          EventType = "emote"           // a player sent an emote
+)
+
+// Random event kinds carried in Event.Kind.
+const (
+	RandomHeal   = "heal"
+	RandomMana   = "mana"
+	RandomDamage = "damage"
+)
+
+// Event is one entry in a match's battle stream. Seq starts at 1 and
+// increases by one per event, so clients can ask for everything after the
+// last Seq they saw.
+type Event struct {
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+
+	// Deploy / Attack / Crit / TowerDestroyed / TroopDefeated
+	Player  string `json:"player,omitempty"`  // owner of the troop involved
+	Troop   string `json:"troop,omitempty"`   // troop involved
+	Tower   string `json:"tower,omitempty"`   // tower involved
+	Counter bool   `json:"counter,omitempty"` // true when the tower is the attacker
+	Damage  int    `json:"damage"`            // damage dealt, 0 when DEF absorbed it
+	HP      int    `json:"hp"`                // defender's HP after the hit
+
+	// RandomEvent, Emote
+	Kind   string `json:"kind,omitempty"`
+	Amount int    `json:"amount,omitempty"`
+
+	// GameOver
+	Winner string       `json:"winner,omitempty"`
+	Reason FinishReason `json:"reason,omitempty"`
+}
+
+// emit stamps events with a sequence number and time and appends them to
+// the stream. Caller must hold gs.mu.
+func (gs *GameState) emit(events ...Event) {
+	now := time.Now()
+	for _, e := range events {
+		e.Seq = len(gs.Events) + 1
+		e.Time = now
+		gs.Events = append(gs.Events, e)
+		battleEvents.WithLabelValues(string(e.Type)).Inc()
+		if e.Type == EventRandomEvent {
+			randomEvents.WithLabelValues(e.Kind).Inc()
+		}
+	}
+}
+
+// EventsSince returns every event with Seq greater than after.
+func (gs *GameState) EventsSince(after int) []Event {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.eventsSince(after)
+}
+
+// eventsSince is EventsSince for callers already holding gs.mu.
+func (gs *GameState) eventsSince(after int) []Event {
+	// Seq n lives at index n-1
+	if after < 0 {
+		after = 0
+	}
+	if after >= len(gs.Events) {
+		return []Event{}
+	}
+	out := make([]Event, len(gs.Events)-after)
+	copy(out, gs.Events[after:])
+	return out
+}
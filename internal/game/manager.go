@@ -1,35 +1,61 @@
 package game
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"clashroyale/internal/auth"
+	"clashroyale/internal/chest"
+	"clashroyale/internal/collection"
 	"clashroyale/internal/lobby"
 	"clashroyale/internal/model"
+	"clashroyale/internal/quest"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/stats"
 	"clashroyale/internal/upgrade"
+	"clashroyale/internal/wallet"
 )
 
 type GameState struct {
 	ID         string
-	Players    [2]*model.Player
-	Towers     [2][]*model.Tower
-	Hands      [2][]*model.Troop
+	Players    []*model.Player
+	Sides      []int             // side (0 or 1) of each player, parallel to Players
+	Towers     [2][]*model.Tower // per side, shared by teammates
+	Hands      [][]*model.Troop  // per player
+	Damage     map[string]int    // damage each player has dealt to towers
 	Mana       map[string]int
 	LastRegen  map[string]time.Time
 	StartTime  time.Time
 	Duration   time.Duration
+	Mode       string // lobby.ModeClassic or lobby.ModeDoubleMana
 	CritChance float64
 	IsFinished bool
-	Winner     string
-	BattleLog  []string
-	mu         sync.Mutex
+	Winner     string       // winning side's player names, or "Draw"
+	WinnerSide int          // -1 until decided or on a draw
+	Loser      string       // who surrendered or forfeited, if anyone
+	Reason     FinishReason // why the game ended, set by FinishGame
+	FinishedAt time.Time
+	Tallies    map[string]*Tally  // how each player is doing, see tally.go
+	Rewards    map[string]*Reward // what each player was paid, set by FinishGame
+	Events     []Event            // battle stream, see events.go
+	QuestSeq   map[string]int     // last event counted toward each player's quests, see quests.go
+	Catalog    *spec.Catalog      // spec version this match started with
+
+	questSaving int // end of the events flushQuests is saving, 0 if it isn't
+
+	// Presence: last contact per player, and who is currently
+	// considered disconnected (see presence.go)
+	LastSeen     map[string]time.Time
+	Disconnected map[string]bool
+
+	// Rematch: who has offered, and the new game once both have
+	RematchOffers map[string]bool
+	RematchID     string
+
+	mu sync.Mutex
 }
 
 var (
@@ -39,60 +65,75 @@ var (
 	}{games: make(map[string]*GameState)}
 
 	// Global lobby manager instance
-	lobbyMgr = lobby.NewManager()
+	lobbyMgr = lobby.NewManager(canMatchmake, arenaTier)
 )
 
-// LoadTroops loads troop data from specs/troops.json
+// LoadTroops returns copies of the troops in the active spec catalog
 func LoadTroops() ([]*model.Troop, error) {
-	data, err := os.ReadFile(filepath.Join("specs", "troops.json"))
+	cat, err := spec.Current()
 	if err != nil {
 		return nil, err
 	}
+	return cat.Troops(), nil
+}
 
-	var troops []*model.Troop
-	if err := json.Unmarshal(data, &troops); err != nil {
+// LoadTowers returns copies of the towers in the active spec catalog
+func LoadTowers() ([]*model.Tower, error) {
+	cat, err := spec.Current()
+	if err != nil {
 		return nil, err
 	}
-	return troops, nil
+	return cat.Towers(), nil
 }
 
-// LoadTowers loads tower data from specs/towers.json
-func LoadTowers() ([]*model.Tower, error) {
-	data, err := os.ReadFile(filepath.Join("specs", "towers.json"))
+// canMatchmake keeps banned and suspended accounts out of the lobby.
+func canMatchmake(username string) error {
+	u, err := auth.LoadUser(username)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return u.CheckMatchmaking(time.Now())
+}
 
-	var towers []*model.Tower
-	if err := json.Unmarshal(data, &towers); err != nil {
-		return nil, err
+// arenaTier puts players in the 1v1 queue by arena, so they meet
+// opponents from their own arena first.
+func arenaTier(username string) int {
+	cat, err := spec.Current()
+	if err != nil {
+		return 0
 	}
-	return towers, nil
+	u, err := auth.LoadUser(username)
+	if err != nil {
+		return 0
+	}
+	_, i := cat.ArenaFor(u.Wallet[wallet.Trophies])
+	return i
 }
 
 // LoadPlayer loads a player from auth system
-func LoadPlayer(username string) *model.Player {
-	user, err := auth.LoadUser(username)
+func LoadPlayer(username string) (*model.Player, error) {
+	cat, err := spec.Current()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return loadPlayer(username, cat)
+}
 
-	// Load towers from JSON
-	towers, err := LoadTowers()
+// loadPlayer builds a player whose towers and levels come from cat
+func loadPlayer(username string, cat *spec.Catalog) (*model.Player, error) {
+	user, err := auth.LoadUser(username)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	towers := cat.Towers()
+
 	// Initialize level maps from user data
 	troopLevels := make(map[string]int)
 	towerLevels := make(map[string]int)
 
 	// Initialize troop levels from user data
-	troops, err := LoadTroops()
-	if err != nil {
-		panic(err)
-	}
-	for _, t := range troops {
+	for _, t := range cat.Troops() {
 		if level, ok := user.TroopLevels[t.Name]; ok {
 			troopLevels[t.Name] = level
 		} else {
@@ -111,20 +152,26 @@ func LoadPlayer(username string) *model.Player {
 
 	// **Apply upgrades** to the towers themselves**
 	for _, t := range towers {
-		lvl := towerLevels[t.Name]
-		t.HP = upgrade.CalculateUpgradeStats(t.HP, lvl)
-		t.ATK = upgrade.CalculateUpgradeStats(t.ATK, lvl)
-		t.DEF = upgrade.CalculateUpgradeStats(t.DEF, lvl)
+		upgrade.LevelTower(cat, t, towerLevels[t.Name])
 	}
 
+	balances := make(wallet.Balances, len(user.Wallet))
+	for c, amt := range user.Wallet {
+		balances[c] = amt
+	}
+
+	// cards the player qualifies for but hasn't had saved yet count too
+	collection.Sync(cat, user)
+
 	return &model.Player{
 		Username:    user.Username,
-		Exp:         user.Exp,
+		Wallet:      balances,
 		Level:       user.Level,
 		Towers:      towers, // now mutated to leveled stats
 		TroopLevels: troopLevels,
 		TowerLevels: towerLevels,
-	}
+		Cards:       user.Cards,
+	}, nil
 }
 
 // cloneTowers creates a deep copy of player's towers
@@ -137,12 +184,21 @@ func cloneTowers(towers []*model.Tower) []*model.Tower {
 	return result
 }
 
+// ownedTroops returns fresh copies of the catalog troops a player has
+// unlocked.
+func (gs *GameState) ownedTroops(playerIndex int) []*model.Troop {
+	var out []*model.Troop
+	for _, t := range gs.Catalog.Troops() {
+		if gs.Players[playerIndex].Owns(t.Name) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
 // drawHand draws a random hand of troops for a player
 func (gs *GameState) drawHand(playerIndex int) {
-	troops, err := LoadTroops()
-	if err != nil {
-		panic(err)
-	}
+	troops := gs.ownedTroops(playerIndex)
 
 	// Shuffle the slice in-place
 	rand.Shuffle(len(troops), func(i, j int) {
@@ -160,10 +216,7 @@ func (gs *GameState) drawHand(playerIndex int) {
 		// Deep-copy each troop so we don't mutate the original spec
 		tCopy := *troops[i]
 		// **apply the player’s level to this clone**
-		lvl := gs.Players[playerIndex].TroopLevels[tCopy.Name]
-		tCopy.HP = upgrade.CalculateUpgradeStats(tCopy.HP, lvl)
-		tCopy.ATK = upgrade.CalculateUpgradeStats(tCopy.ATK, lvl)
-		tCopy.DEF = upgrade.CalculateUpgradeStats(tCopy.DEF, lvl)
+		upgrade.LevelTroop(gs.Catalog, &tCopy, gs.Players[playerIndex].TroopLevels[tCopy.Name])
 
 		hand[i] = &tCopy
 	}
@@ -173,9 +226,9 @@ func (gs *GameState) drawHand(playerIndex int) {
 
 // drawNewTroop draws a single new troop for a player
 func (gs *GameState) drawNewTroop(playerIndex int) {
-	troops, err := LoadTroops()
-	if err != nil {
-		panic(err)
+	troops := gs.ownedTroops(playerIndex)
+	if len(troops) == 0 {
+		return
 	}
 
 	// Shuffle the slice in-place
@@ -185,240 +238,132 @@ func (gs *GameState) drawNewTroop(playerIndex int) {
 
 	// Take the first troop
 	tCopy := *troops[0]
-	lvl := gs.Players[playerIndex].TroopLevels[tCopy.Name]
-	tCopy.HP = upgrade.CalculateUpgradeStats(tCopy.HP, lvl)
-	tCopy.ATK = upgrade.CalculateUpgradeStats(tCopy.ATK, lvl)
-	tCopy.DEF = upgrade.CalculateUpgradeStats(tCopy.DEF, lvl)
+	upgrade.LevelTroop(gs.Catalog, &tCopy, gs.Players[playerIndex].TroopLevels[tCopy.Name])
 	gs.Hands[playerIndex] = append(gs.Hands[playerIndex], &tCopy)
 }
 
-func GetOrCreate(gameID string) *GameState {
+// Get returns a game that has already started, without starting one
+func Get(gameID string) (*GameState, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	gs, ok := mgr.games[gameID]
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return gs, nil
+}
+
+// forget drops a game from the manager.
+func forget(gameID string) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	delete(mgr.games, gameID)
+}
+
+// GetOrCreate returns the running game, starting it on first access
+func GetOrCreate(gameID string) (*GameState, error) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
 	if gs, ok := mgr.games[gameID]; ok {
-		return gs
+		return gs, nil
 	}
 
-	pair := lobbyMgr.GetPlayers(gameID)
-	if pair[0] == "" || pair[1] == "" {
-		return nil
+	match, ok := lobbyMgr.GetMatch(gameID)
+	if !ok {
+		return nil, ErrGameNotFound
 	}
 
-	p1 := LoadPlayer(pair[0])
-	p2 := LoadPlayer(pair[1])
+	// Pin the current spec catalog so a reload mid-match doesn't change it
+	cat, err := spec.Current()
+	if err != nil {
+		return nil, err
+	}
 
+	now := time.Now()
 	gs := &GameState{
 		ID:         gameID,
-		Players:    [2]*model.Player{p1, p2},
-		Towers:     [2][]*model.Tower{cloneTowers(p1.Towers), cloneTowers(p2.Towers)},
-		Hands:      [2][]*model.Troop{make([]*model.Troop, 0), make([]*model.Troop, 0)},
-		Mana:       map[string]int{p1.Username: 5, p2.Username: 5},
-		LastRegen:  map[string]time.Time{p1.Username: time.Now(), p2.Username: time.Now()},
-		StartTime:  time.Now(),
-		Duration:   3 * time.Minute,
+		Sides:      match.Sides,
+		Hands:      make([][]*model.Troop, len(match.Players)),
+		Damage:     make(map[string]int),
+		Tallies:    make(map[string]*Tally),
+		QuestSeq:   make(map[string]int),
+		Mana:       make(map[string]int),
+		LastRegen:  make(map[string]time.Time),
+		StartTime:  now,
+		Duration:   match.Options.Duration,
+		Mode:       match.Options.Mode,
 		CritChance: 0.1,
 		IsFinished: false,
+		WinnerSide: -1,
+		Catalog:    cat,
+
+		LastSeen:     make(map[string]time.Time),
+		Disconnected: make(map[string]bool),
+
+		RematchOffers: make(map[string]bool),
+	}
+	for _, name := range match.Players {
+		p, err := loadPlayer(name, cat)
+		if err != nil {
+			return nil, err
+		}
+		gs.Players = append(gs.Players, p)
+		gs.Mana[name] = 5
+		gs.LastRegen[name] = now
+		// everyone gets a full grace window to load the game page
+		gs.LastSeen[name] = now
+	}
+	for side := range gs.Towers {
+		gs.Towers[side] = sideTowers(cat, gs.Members(side))
 	}
 
-	// Draw initial hands for both players
-	gs.drawHand(0)
-	gs.drawHand(1)
+	// Draw initial hands for every player
+	for i := range gs.Players {
+		gs.drawHand(i)
+	}
 
 	// start the 30-second random events
 	gs.startRandomEvents()
+	gs.watchConnections()
+	gs.trackQuests()
 
 	mgr.games[gameID] = gs
-	return gs
+	return gs, nil
 }
 
 func (gs *GameState) RegenMana(user string) {
 	now := time.Now()
-	elapsed := now.Sub(gs.LastRegen[user]).Seconds()
-	//regen 1 mana per sec
-	if elapsed >= 1 {
-		add := int(elapsed)
-		gs.Mana[user] = min(10, gs.Mana[user]+add)
+	if now.Sub(gs.LastRegen[user]) >= time.Second {
+		gs.Mana[user] = gs.manaAt(user, now)
 		gs.LastRegen[user] = now
 	}
 }
 
-// AddBattleLog adds a new entry to the battle log
-func (gs *GameState) AddBattleLog(entry string) {
-	// No need to lock here since we're already holding the lock in Deploy
-	gs.BattleLog = append(gs.BattleLog, entry)
-}
-
-// GetBattleLog returns the current battle log
-func (gs *GameState) GetBattleLog() []string {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-	return gs.BattleLog
-}
-
-func (gs *GameState) Deploy(username, troopName string) error {
-	gs.mu.Lock()
-	if gs.IsFinished {
-		gs.mu.Unlock()
-		return errors.New("game already finished")
+// manaAt reports how much mana user would have at now, without regenerating
+func (gs *GameState) manaAt(user string, now time.Time) int {
+	//regen 1 mana per sec, 2 in double mana mode
+	add := int(now.Sub(gs.LastRegen[user]).Seconds())
+	if gs.Mode == lobby.ModeDoubleMana {
+		add *= 2
 	}
-
-	//check time
-	if time.Since(gs.StartTime) > gs.Duration {
-		gs.mu.Unlock()
-		gs.FinishGame()
-		return errors.New("game time is up")
-	}
-
-	//regen mana
-	gs.RegenMana(username)
-	troop := gs.findAndRemoveTroop(username, troopName)
-	if troop == nil {
-		gs.mu.Unlock()
-		return errors.New("troop not found in hand")
-	}
-
-	// Draw a new troop to replace the deployed one
-	playerIndex := gs.PlayerIndex(username)
-	gs.drawNewTroop(playerIndex)
-
-	//mana cost check
-	if gs.Mana[username] < troop.Cost {
-		gs.mu.Unlock()
-		return errors.New("not enough mana")
-	}
-	gs.Mana[username] -= troop.Cost
-
-	//fin target: first alive guard > second >king
-	idx := gs.PlayerIndex(username)
-	enemy := 1 - idx
-	var target *model.Tower
-
-	// First try to find a Guard Tower
-	for _, tw := range gs.Towers[enemy] {
-		if tw.HP > 0 && tw.Name == "Guard Tower" {
-			target = tw
-			break
-		}
-	}
-
-	// If no Guard Tower is alive, target the King Tower
-	if target == nil {
-		for _, tw := range gs.Towers[enemy] {
-			if tw.HP > 0 && tw.Name == "King Tower" {
-				target = tw
-				break
-			}
-		}
-	}
-
-	if target == nil {
-		// all dead > win
-		gs.Winner = username
-		gs.mu.Unlock()
-		gs.FinishGame()
-		return nil
-	}
-
-	// Combat loop - continue until troop is defeated or target is destroyed
-	for troop.HP > 0 && target.HP > 0 {
-		// Troop attacks tower
-		atk := troop.ATK
-		isCrit := rand.Float64() < gs.CritChance
-		if isCrit {
-			atk = int(float64(atk) * 1.2)
-		}
-		dmg := atk - target.DEF
-		if dmg > 0 {
-			target.HP -= dmg
-			// Log the attack
-			critMsg := ""
-			if isCrit {
-				critMsg = " (CRITICAL HIT!)"
-			}
-			gs.AddBattleLog(fmt.Sprintf("⚔️%s's %s attacks %s for %d damage%s", username, troop.Name, target.Name, dmg, critMsg))
-			gs.AddBattleLog(fmt.Sprintf("🏰 %s now has %d HP", target.Name, target.HP))
-		} else {
-			gs.AddBattleLog(fmt.Sprintf("🔰%s's %s attacks %s but deals no damage (DEF too high)", username, troop.Name, target.Name))
-		}
-
-		// Check if tower is destroyed
-		if target.HP <= 0 {
-			gs.AddBattleLog(fmt.Sprintf("💥 %s has been destroyed!", target.Name))
-			break
-		}
-
-		// Tower counter-attacks
-		towerAtk := target.ATK
-		towerIsCrit := rand.Float64() < target.Crit
-		if towerIsCrit {
-			towerAtk = int(float64(towerAtk) * 1.2)
-		}
-		towerDmg := towerAtk - troop.DEF
-		if towerDmg > 0 {
-			troop.HP -= towerDmg
-			critMsg := ""
-			if towerIsCrit {
-				critMsg = " (CRITICAL HIT!)"
-			}
-			gs.AddBattleLog(fmt.Sprintf("🗡️%s counter-attacks %s for %d damage%s", target.Name, troop.Name, towerDmg, critMsg))
-			gs.AddBattleLog(fmt.Sprintf("💔 %s now has %d HP", troop.Name, troop.HP))
-		} else {
-			gs.AddBattleLog(fmt.Sprintf("❌ %s counter-attacks %s but deals no damage (DEF too high)", target.Name, troop.Name))
-		}
-
-		// Check if troop is destroyed
-		if troop.HP <= 0 {
-			gs.AddBattleLog(fmt.Sprintf("☠️ %s has been defeated!", troop.Name))
-			break
-		}
-	}
-
-	// Check if all towers are destroyed
-	allTowersDestroyed := true
-	for _, tw := range gs.Towers[enemy] {
-		if tw.HP > 0 {
-			allTowersDestroyed = false
-			break
-		}
-	}
-
-	if allTowersDestroyed {
-		gs.Winner = username
-		gs.mu.Unlock()
-		gs.FinishGame()
-		return nil
-	}
-
-	if time.Since(gs.StartTime) > gs.Duration {
-		gs.mu.Unlock()
-		gs.FinishGame()
-		return nil
-	}
-
-	gs.mu.Unlock()
-	return nil
+	return min(spec.MaxMana, gs.Mana[user]+max(add, 0))
 }
 
-// find and remove
-func (gs *GameState) findAndRemoveTroop(username, troopName string) *model.Troop {
-	idx := gs.PlayerIndex(username)
-	for i, t := range gs.Hands[idx] {
-		if t.Name == troopName {
-			//remove from hand
-			gs.Hands[idx] = append(gs.Hands[idx][:i], gs.Hands[idx][i+1:]...)
-			return t
+// player index, -1 if username isn't playing
+func (gs *GameState) PlayerIndex(username string) int {
+	for i, p := range gs.Players {
+		if p.Username == username {
+			return i
 		}
 	}
-	return nil
+	return -1
 }
 
-// player index
-func (gs *GameState) PlayerIndex(username string) int {
-	if gs.Players[0].Username == username {
-		return 0
-	}
-	return 1
+// HasPlayer reports whether username is playing in this game
+func (gs *GameState) HasPlayer(username string) bool {
+	return gs.PlayerIndex(username) >= 0
 }
 
 // Kick off a background ticker that applies a random event every 30s.
@@ -442,14 +387,14 @@ func (gs *GameState) startRandomEvents() {
 						tw.HP += 10
 					}
 				}
-				gs.AddBattleLog("🔮 Random Event: All towers healed by 10 HP")
+				gs.emit(Event{Type: EventRandomEvent, Kind: RandomHeal, Amount: 10})
 
 			case 1:
-				// Give every player +10 mana (cap at, say, 10)
+				// Give every player +10 mana (capped at MaxMana)
 				for user := range gs.Mana {
-					gs.Mana[user] = min(10, gs.Mana[user]+10)
+					gs.Mana[user] = min(spec.MaxMana, gs.Mana[user]+10)
 				}
-				gs.AddBattleLog("🔮 Random Event: All players gain 10 mana")
+				gs.emit(Event{Type: EventRandomEvent, Kind: RandomMana, Amount: 10})
 
 			case 2:
 				// Damage every tower by 2 HP
@@ -458,7 +403,7 @@ func (gs *GameState) startRandomEvents() {
 						tw.HP -= 2
 					}
 				}
-				gs.AddBattleLog("🔮 Random Event: All towers take 2 damage")
+				gs.emit(Event{Type: EventRandomEvent, Kind: RandomDamage, Amount: 2})
 			}
 
 			gs.mu.Unlock()
@@ -466,83 +411,105 @@ func (gs *GameState) startRandomEvents() {
 	}()
 }
 
-// FinishGame decides the winner and awards EXP
-func (gs *GameState) FinishGame() {
+// FinishReason records why a game ended.
+type FinishReason string
+
+const (
+	ReasonTowersDestroyed FinishReason = "towers_destroyed"
+	ReasonTimeUp          FinishReason = "time_up"
+	ReasonForfeit         FinishReason = "forfeit"   // a player disconnected past the grace period
+	ReasonSurrender       FinishReason = "surrender" // a player conceded
+	ReasonAdmin           FinishReason = "admin"     // ended by a moderator
+)
+
+// FinishGame decides the winner and pays out the match rewards.
+// The game is marked finished even if saving the results fails.
+func (gs *GameState) FinishGame(reason FinishReason) error {
 	gs.mu.Lock()
 	if gs.IsFinished {
 		gs.mu.Unlock()
-		return
+		return nil
 	}
+	err := gs.finish(reason)
+	r := gs.result()
+	gs.mu.Unlock()
+
+	gs.afterFinish(r)
+	return err
+}
+
+// afterFinish is the rest of FinishGame, run once finish has marked the
+// game over and gs.mu is released: the hooks may well call back into the
+// game. Callers that decide the winner themselves call finish under the
+// same lock, so nothing can change it in between, then this.
+func (gs *GameState) afterFinish(r Result) {
+	// Remove game from lobby manager whatever happened in finish, then
+	// tell anyone listening for results
+	lobbyMgr.RemoveGame(gs.ID)
+	notifyFinish(r)
+
+	// the result screen and rematch offers need the game until the
+	// rematch window closes, nothing does after
+	time.AfterFunc(RematchWindow, func() { forget(gs.ID) })
+}
+
+// finish does the work of FinishGame. Caller must hold gs.mu.
+func (gs *GameState) finish(reason FinishReason) error {
 	gs.IsFinished = true
+	gs.Reason = reason
+	gs.FinishedAt = time.Now()
+	matchesFinished.WithLabelValues(string(reason)).Inc()
 
 	//not king kill, decide tower left
-	if gs.Winner == "" {
+	if gs.WinnerSide < 0 && gs.Winner == "" {
 		counts := [2]int{}
-		for pi := 0; pi < 2; pi++ {
-			for _, tw := range gs.Towers[pi] {
+		for side := range gs.Towers {
+			for _, tw := range gs.Towers[side] {
 				if tw.HP > 0 {
-					counts[pi]++
+					counts[side]++
 				}
 			}
 		}
 		switch {
 		case counts[0] > counts[1]:
-			gs.Winner = gs.Players[0].Username
+			gs.setWinner(0)
 		case counts[0] < counts[1]:
-			gs.Winner = gs.Players[1].Username
+			gs.setWinner(1)
 		default:
 			gs.Winner = "Draw"
 		}
 	}
-	//award winner
-	p1, p2 := gs.Players[0], gs.Players[1]
-	switch gs.Winner {
-	case p1.Username:
-		p1.Exp += 30
-		p2.Exp += 0
-	case p2.Username:
-		p1.Exp += 0
-		p2.Exp += 30
-	default:
-		p1.Exp += 10
-		p2.Exp += 10
-	}
 
-	// Add final battle log entry
-	gs.AddBattleLog(fmt.Sprintf("Game Over! Winner: %s", gs.Winner))
+	gs.workOutRewards()
 
-	// Load existing user data to preserve password hash and levels
-	u1, err := auth.LoadUser(p1.Username)
-	if err != nil {
-		panic(err)
-	}
-	u2, err := auth.LoadUser(p2.Username)
-	if err != nil {
-		panic(err)
-	}
+	// Close the battle stream
+	gs.emit(Event{Type: EventGameOver, Winner: gs.Winner, Player: gs.Loser, Reason: reason})
 
-	// Update user data while preserving existing fields
-	u1.Exp = p1.Exp
-	u1.Level = p1.Level
-	u1.TroopLevels = p1.TroopLevels
-	u1.TowerLevels = p1.TowerLevels
-
-	u2.Exp = p2.Exp
-	u2.Level = p2.Level
-	u2.TroopLevels = p2.TroopLevels
-	u2.TowerLevels = p2.TowerLevels
-
-	// Save updated user data
-	if err := auth.SaveUser(u1); err != nil {
-		panic(err)
-	}
-	if err := auth.SaveUser(u2); err != nil {
-		panic(err)
+	// Pay every player, reporting every failure rather than stopping at the first
+	var errs []error
+	for _, p := range gs.Players {
+		r := gs.Rewards[p.Username]
+		_, applied, err := auth.Transact(p.Username, r.due(), wallet.ReasonMatch, gs.ID, func(u *auth.User) error {
+			// new trophies can unlock cards
+			r.Unlocked = collection.Sync(gs.Catalog, u)
+			if r.Outcome == spec.OutcomeWin {
+				c, err := u.Chests.Drop(gs.Catalog, chest.Clock())
+				r.Chest, r.NoSlot = c.Type, errors.Is(err, chest.ErrSlotsFull)
+			}
+			u.Quests.Rotate(gs.Catalog, quest.Clock())
+			u.Quests.Record(gs.Catalog, gs.questProgress(p.Username))
+			u.Stats.Record(gs.summary(p.Username))
+			r.Achievements = stats.Award(gs.Catalog, &u.Achievements, u.Stats, time.Now())
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pay %s: %w", p.Username, err))
+			continue
+		}
+		r.Paid = applied
+		gs.QuestSeq[p.Username] = len(gs.Events)
 	}
-
-	// Remove game from lobby manager
-	lobbyMgr.RemoveGame(gs.ID)
-	gs.mu.Unlock()
+	return errors.Join(errs...)
 }
 
 // GetLobbyManager returns the global lobby manager instance
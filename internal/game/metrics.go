@@ -0,0 +1,79 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	matchesFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_matches_finished_total",
+		Help: "Matches that have ended, by why they ended.",
+	}, []string{"reason"})
+
+	deploys = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clashroyale_deploys_total",
+		Help: "Troops deployed.",
+	})
+
+	deployRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_deploy_rejections_total",
+		Help: "Deploys refused by validation, by reason.",
+	}, []string{"reason"})
+
+	randomEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_random_events_total",
+		Help: "Random battle events fired, by kind.",
+	}, []string{"kind"})
+
+	battleEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_battle_events_total",
+		Help: "Battle events emitted, by type.",
+	}, []string{"type"})
+)
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "clashroyale_matches_active",
+		Help: "Matches being played right now.",
+	}, func() float64 {
+		mgr.mu.Lock()
+		games := make([]*GameState, 0, len(mgr.games))
+		for _, gs := range mgr.games {
+			games = append(games, gs)
+		}
+		mgr.mu.Unlock()
+
+		active := 0
+		for _, gs := range games {
+			gs.mu.Lock()
+			if !gs.IsFinished {
+				active++
+			}
+			gs.mu.Unlock()
+		}
+		return float64(active)
+	})
+	prometheus.MustRegister(lobbyMgr.Collector())
+}
+
+// rejectReason labels a deploy validation error for
+// clashroyale_deploy_rejections_total.
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrNotEnoughMana):
+		return "not_enough_mana"
+	case errors.Is(err, ErrTroopNotInHand):
+		return "troop_not_in_hand"
+	case errors.Is(err, ErrGameFinished):
+		return "game_finished"
+	case errors.Is(err, ErrTimeUp):
+		return "time_up"
+	case errors.Is(err, ErrNotInGame):
+		return "not_in_game"
+	default:
+		return "other"
+	}
+}
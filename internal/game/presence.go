@@ -0,0 +1,108 @@
+package game
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// DisconnectAfter is how long a player can go without polling before
+	// their opponent is told they've disconnected.
+	DisconnectAfter = 5 * time.Second
+
+	// ReconnectGrace is how long a disconnected player has to come back
+	// before they forfeit.
+	ReconnectGrace = 30 * time.Second
+)
+
+// Connection states reported in PublicState.OpponentStatus.
+const (
+	StatusConnected    = "connected"
+	StatusDisconnected = "disconnected"
+)
+
+// Touch records contact from user. It reports whether the user is coming
+// back from a disconnect, in which case the client should be sent a full
+// resync rather than a delta.
+func (gs *GameState) Touch(user string) (reconnected bool) {
+	if !gs.HasPlayer(user) {
+		return false
+	}
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.Disconnected[user] && !gs.IsFinished {
+		delete(gs.Disconnected, user)
+		gs.emit(Event{Type: EventReconnected, Player: user})
+		reconnected = true
+	}
+	gs.LastSeen[user] = time.Now()
+	return reconnected
+}
+
+// connectionStatus reports user's connection state and, if disconnected,
+// how many seconds of grace they have left. Caller must hold gs.mu.
+func (gs *GameState) connectionStatus(user string, now time.Time) (string, int) {
+	if !gs.Disconnected[user] {
+		return StatusConnected, 0
+	}
+	left := gs.LastSeen[user].Add(DisconnectAfter + ReconnectGrace).Sub(now)
+	return StatusDisconnected, max(int(left.Seconds()), 0)
+}
+
+// watchConnections marks players disconnected when they stop polling and
+// forfeits them once the grace period runs out.
+func (gs *GameState) watchConnections() {
+	ticker := time.NewTicker(time.Second)
+	go func() {
+		defer ticker.Stop()
+		for now := range ticker.C {
+			gs.mu.Lock()
+			if gs.IsFinished {
+				gs.mu.Unlock()
+				return
+			}
+
+			// a side forfeits once all of its players are gone
+			gone := [2]int{}
+			for i, p := range gs.Players {
+				idle := now.Sub(gs.LastSeen[p.Username])
+				if idle > DisconnectAfter && !gs.Disconnected[p.Username] {
+					gs.Disconnected[p.Username] = true
+					gs.emit(Event{Type: EventDisconnected, Player: p.Username})
+				}
+				if idle > DisconnectAfter+ReconnectGrace {
+					gone[gs.Sides[i]]++
+				}
+			}
+
+			out := [2]bool{}
+			for side := range out {
+				out[side] = gone[side] == len(gs.Members(side))
+			}
+			if !out[0] && !out[1] {
+				gs.mu.Unlock()
+				continue
+			}
+			if out[0] != out[1] {
+				// the side still here wins by forfeit
+				loser := 0
+				if out[1] {
+					loser = 1
+				}
+				gs.setWinner(1 - loser)
+				gs.Loser = gs.sideName(loser)
+			}
+			// if everyone left, nobody is owed a forfeit win: fall back to towers
+			err := gs.finish(ReasonForfeit)
+			r := gs.result()
+			gs.mu.Unlock()
+
+			gs.afterFinish(r)
+			if err != nil {
+				log.Printf("game %s: finishing after a forfeit: %v", gs.ID, err)
+			}
+			return
+		}
+	}()
+}
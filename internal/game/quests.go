@@ -0,0 +1,108 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/model"
+	"clashroyale/internal/quest"
+)
+
+// QuestFlushInterval is how often a running match counts its new battle
+// events toward its players' quests. Whatever is left is counted when the
+// match finishes, so a match that never does still moves quests along.
+const QuestFlushInterval = 30 * time.Second
+
+// questProgress is what username did in the events not yet counted
+// toward their quests, nor being saved by flushQuests. Outcome stays
+// empty until the stream reaches the end of the match. Caller must hold
+// gs.mu.
+func (gs *GameState) questProgress(username string) model.MatchSummary {
+	m := model.MatchSummary{Deploys: make(map[string]int)}
+	for _, e := range gs.eventsSince(max(gs.QuestSeq[username], gs.questSaving)) {
+		if e.Type == EventGameOver {
+			if r, ok := gs.Rewards[username]; ok {
+				m.Outcome = r.Outcome
+			}
+			continue
+		}
+		if e.Player != username {
+			continue
+		}
+		switch e.Type {
+		case EventDeploy:
+			m.Deploys[e.Troop]++
+		case EventTowerDestroyed:
+			m.Destroyed = append(m.Destroyed, e.Tower)
+		case EventAttack, EventCrit:
+			if !e.Counter {
+				m.Damage += e.Damage
+			}
+		}
+	}
+	return m
+}
+
+// trackQuests counts the battle stream toward quests every
+// QuestFlushInterval until the match finishes.
+func (gs *GameState) trackQuests() {
+	ticker := time.NewTicker(QuestFlushInterval)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C {
+			if !gs.flushQuests() {
+				return
+			}
+		}
+	}()
+}
+
+// flushQuests saves every player's quest progress from the events since
+// the last flush, and reports whether the match is still running. Saving
+// goes through the accounts, so it runs without gs.mu; meanwhile
+// questSaving keeps finish from counting the same events again. A player
+// whose save fails has them counted next time, unless the match finishes
+// first.
+func (gs *GameState) flushQuests() bool {
+	gs.mu.Lock()
+	if gs.IsFinished {
+		gs.mu.Unlock()
+		return false
+	}
+	seq, cat := len(gs.Events), gs.Catalog
+	progress := make(map[string]model.MatchSummary, len(gs.Players))
+	for _, p := range gs.Players {
+		progress[p.Username] = gs.questProgress(p.Username)
+	}
+	gs.questSaving = seq
+	gs.mu.Unlock()
+
+	var saved []string
+	for username, m := range progress {
+		if len(m.Deploys) > 0 || len(m.Destroyed) > 0 || m.Damage > 0 {
+			_, err := auth.Update(username, func(u *auth.User) error {
+				u.Quests.Rotate(cat, quest.Clock())
+				u.Quests.Record(cat, m)
+				return nil
+			})
+			if err != nil {
+				log.Printf("game %s: quests for %s: %v", gs.ID, username, err)
+				continue
+			}
+		}
+		saved = append(saved, username)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.questSaving = 0
+	if gs.IsFinished {
+		// finish counted everything after seq
+		return false
+	}
+	for _, username := range saved {
+		gs.QuestSeq[username] = seq
+	}
+	return true
+}
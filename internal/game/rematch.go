@@ -0,0 +1,73 @@
+package game
+
+import (
+	"time"
+
+	"clashroyale/internal/lobby"
+)
+
+// RematchWindow is how long after a game ends a rematch can be agreed.
+const RematchWindow = 2 * time.Minute
+
+// Surrender concedes the game to the opponent.
+func (gs *GameState) Surrender(user string) error {
+	if !gs.HasPlayer(user) {
+		return ErrNotInGame
+	}
+
+	gs.mu.Lock()
+	if gs.IsFinished {
+		gs.mu.Unlock()
+		return ErrGameFinished
+	}
+	// conceding concedes for the whole team
+	gs.setWinner(1 - gs.Side(user))
+	gs.Loser = user
+	err := gs.finish(ReasonSurrender)
+	r := gs.result()
+	gs.mu.Unlock()
+
+	gs.afterFinish(r)
+	return err
+}
+
+// OfferRematch records that user wants to play the same opponents again.
+// Once every player has offered, a new game is created with the same
+// players, bypassing the lobby queue, and its ID returned. Until then the
+// returned ID is empty.
+func (gs *GameState) OfferRematch(user string) (string, error) {
+	if !gs.HasPlayer(user) {
+		return "", ErrNotInGame
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if !gs.IsFinished {
+		return "", ErrGameNotFinished
+	}
+	if gs.RematchID != "" {
+		return gs.RematchID, nil
+	}
+	if time.Since(gs.FinishedAt) > RematchWindow {
+		return "", ErrRematchExpired
+	}
+
+	gs.RematchOffers[user] = true
+	match := lobby.Match{Options: lobby.Options{Duration: gs.Duration, Mode: gs.Mode}}
+	for i, p := range gs.Players {
+		if !gs.RematchOffers[p.Username] {
+			return "", nil
+		}
+		// swap sides so the same team isn't always listed first
+		match.Players = append(match.Players, p.Username)
+		match.Sides = append(match.Sides, 1-gs.Sides[i])
+	}
+
+	id, err := lobbyMgr.StartGame(match)
+	if err != nil {
+		return "", err
+	}
+	gs.RematchID = id
+	return id, nil
+}
@@ -0,0 +1,63 @@
+package game
+
+import "clashroyale/internal/i18n"
+
+// RenderEvent turns a battle event into the battle log line shown to a
+// viewer using tr's locale.
+func RenderEvent(tr *i18n.Translator, e Event) string {
+	crit := ""
+	if e.Type == EventCrit {
+		crit = tr.T("event.crit")
+	}
+
+	switch e.Type {
+	case EventDeploy:
+		return tr.T("event.deploy", "player", e.Player, "troop", e.Troop)
+
+	case EventAttack, EventCrit:
+		key := "event.attack"
+		if e.Counter {
+			key = "event.counter"
+		}
+		if e.Damage <= 0 {
+			key += "_blocked"
+		}
+		return tr.T(key,
+			"player", e.Player, "troop", e.Troop, "tower", e.Tower,
+			"damage", e.Damage, "hp", e.HP, "crit", crit)
+
+	case EventTowerDestroyed:
+		return tr.T("event.tower_destroyed", "tower", e.Tower)
+
+	case EventTroopDefeated:
+		return tr.T("event.troop_defeated", "troop", e.Troop)
+
+	case EventRandomEvent:
+		return tr.T("event.random."+e.Kind, "amount", e.Amount)
+
+	case EventDisconnected:
+		return tr.T("event.disconnected", "player", e.Player, "seconds", int(ReconnectGrace.Seconds()))
+
+	case EventReconnected:
+		return tr.T("event.reconnected", "player", e.Player)
+
+	case EventEmote:
+		return tr.T("event.emote", "player", e.Player, "emote", tr.T("emote."+e.Kind))
+
+	case EventGameOver:
+		if e.Winner == "Draw" {
+			return tr.T("event.game_over_draw")
+		}
+		if e.Reason == ReasonSurrender {
+			return tr.T("event.game_over_surrender", "winner", e.Winner, "player", e.Player)
+		}
+		if e.Reason == ReasonAdmin {
+			return tr.T("event.game_over_admin", "winner", e.Winner)
+		}
+		if e.Reason == ReasonForfeit {
+			return tr.T("event.game_over_forfeit", "winner", e.Winner)
+		}
+		return tr.T("event.game_over", "winner", e.Winner)
+	}
+	return string(e.Type)
+}
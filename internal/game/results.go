@@ -0,0 +1,62 @@
+package game
+
+import "sync"
+
+// Result is what FinishGame reports to other subsystems once a game is
+// over, e.g. tournaments advancing their brackets.
+type Result struct {
+	GameID     string
+	Players    []string
+	Sides      []int // parallel to Players
+	WinnerSide int   // -1 on a draw
+	Reason     FinishReason
+}
+
+// Winners returns the players on the winning side, nil on a draw.
+func (r Result) Winners() []string {
+	var names []string
+	for i, p := range r.Players {
+		if r.WinnerSide >= 0 && r.Sides[i] == r.WinnerSide {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+var finishHooks struct {
+	fns []func(Result)
+	mu  sync.Mutex
+}
+
+// OnFinish registers fn to be called with every finished game's result.
+// Hooks run after the game has left the lobby, so its players are free to
+// be put in a new match, and must not call back into the finished game.
+func OnFinish(fn func(Result)) {
+	finishHooks.mu.Lock()
+	defer finishHooks.mu.Unlock()
+	finishHooks.fns = append(finishHooks.fns, fn)
+}
+
+// notifyFinish runs the registered hooks with r.
+func notifyFinish(r Result) {
+	finishHooks.mu.Lock()
+	fns := append([]func(Result){}, finishHooks.fns...)
+	finishHooks.mu.Unlock()
+	for _, fn := range fns {
+		fn(r)
+	}
+}
+
+// result summarizes a finished game. Caller must hold gs.mu.
+func (gs *GameState) result() Result {
+	r := Result{
+		GameID:     gs.ID,
+		Sides:      append([]int(nil), gs.Sides...),
+		WinnerSide: gs.WinnerSide,
+		Reason:     gs.Reason,
+	}
+	for _, p := range gs.Players {
+		r.Players = append(r.Players, p.Username)
+	}
+	return r
+}
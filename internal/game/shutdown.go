@@ -0,0 +1,179 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"clashroyale/internal/lobby"
+)
+
+// SnapshotDir is where matches still running at shutdown are saved until
+// the next start.
+var SnapshotDir = filepath.Join("data", "matches")
+
+// snapshot is one saved match. Game is nil when the lobby had paired the
+// players but nobody had opened the game page yet.
+type snapshot struct {
+	ID      string      `json:"id"`
+	Match   lobby.Match `json:"match"`
+	Game    *GameState  `json:"game,omitempty"`
+	SavedAt time.Time   `json:"savedAt"`
+}
+
+// Drain closes the lobby so no new matches start, then waits up to
+// timeout for the ones in progress to end. It reports whether they all did.
+func Drain(timeout time.Duration) bool {
+	lobbyMgr.Close()
+	deadline := time.Now().Add(timeout)
+	for len(lobbyMgr.Matches()) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return true
+}
+
+// SaveMatches writes every unfinished match to dir, one JSON file each,
+// and returns how many it saved. Call it after Drain, once nothing can
+// reach the games any more.
+func SaveMatches(dir string) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	saved := 0
+	var errs []error
+	for id, mt := range lobbyMgr.Matches() {
+		mgr.mu.Lock()
+		gs := mgr.games[id]
+		mgr.mu.Unlock()
+
+		b, err := encodeSnapshot(snapshot{ID: id, Match: mt, Game: gs, SavedAt: now})
+		if err == nil && b == nil {
+			continue // finished while we were looking
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, id+".json"), b, 0o644)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("save match %s: %w", id, err))
+			continue
+		}
+		saved++
+	}
+	return saved, errors.Join(errs...)
+}
+
+// encodeSnapshot marshals s while holding its game's lock. It returns
+// nil if the game has already finished.
+func encodeSnapshot(s snapshot) ([]byte, error) {
+	if s.Game != nil {
+		s.Game.mu.Lock()
+		defer s.Game.mu.Unlock()
+		if s.Game.IsFinished {
+			return nil, nil
+		}
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// RestoreMatches loads the matches saved in dir back into the lobby and
+// the game manager, and deletes their files. Clocks carry on from where
+// they stopped, so the downtime doesn't count against the match, and
+// every player gets a full reconnect grace period. A file that can't be
+// read is logged and left in place.
+func RestoreMatches(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			log.Printf("restore %s: %v", f, err)
+			continue
+		}
+		var s snapshot
+		if err := json.Unmarshal(b, &s); err != nil {
+			log.Printf("restore %s: %v", f, err)
+			continue
+		}
+
+		if gs := s.Game; gs != nil {
+			if err := gs.resume(time.Since(s.SavedAt)); err != nil {
+				log.Printf("restore %s: %v", f, err)
+				continue
+			}
+			mgr.mu.Lock()
+			mgr.games[s.ID] = gs
+			mgr.mu.Unlock()
+		}
+		lobbyMgr.Restore(s.ID, s.Match)
+
+		if err := os.Remove(f); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// resume readies a game decoded from a snapshot taken downtime ago and
+// restarts its background loops.
+func (gs *GameState) resume(downtime time.Duration) error {
+	if gs.Catalog == nil {
+		return errors.New("snapshot has no spec catalog")
+	}
+	if len(gs.Players) == 0 || len(gs.Sides) != len(gs.Players) || len(gs.Hands) != len(gs.Players) {
+		return errors.New("snapshot has inconsistent players")
+	}
+
+	// shift the clocks past the downtime
+	gs.StartTime = gs.StartTime.Add(downtime)
+	for u, t := range gs.LastRegen {
+		gs.LastRegen[u] = t.Add(downtime)
+	}
+
+	now := time.Now()
+	if gs.LastSeen == nil {
+		gs.LastSeen = make(map[string]time.Time)
+	}
+	for _, p := range gs.Players {
+		gs.LastSeen[p.Username] = now
+	}
+	if gs.Disconnected == nil {
+		gs.Disconnected = make(map[string]bool)
+	}
+	if gs.RematchOffers == nil {
+		gs.RematchOffers = make(map[string]bool)
+	}
+	for _, m := range []*map[string]int{&gs.Damage, &gs.Mana} {
+		if *m == nil {
+			*m = make(map[string]int)
+		}
+	}
+	if gs.LastRegen == nil {
+		gs.LastRegen = make(map[string]time.Time)
+	}
+	if gs.Tallies == nil {
+		gs.Tallies = make(map[string]*Tally)
+	}
+	if gs.QuestSeq == nil {
+		// saved before quests followed the battle stream: nothing counted yet
+		gs.QuestSeq = make(map[string]int)
+	}
+
+	gs.startRandomEvents()
+	gs.watchConnections()
+	gs.trackQuests()
+	return nil
+}
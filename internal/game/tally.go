@@ -0,0 +1,140 @@
+package game
+
+import (
+	"math"
+
+	"clashroyale/internal/model"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+)
+
+// Tally is one player's performance in a match, kept up to date as they
+// deploy. The damage they've dealt is in GameState.Damage.
+type Tally struct {
+	Deploys         int            `json:"deploys"`
+	Troops          map[string]int `json:"troops"`   // deploys by troop
+	TroopExp        int            `json:"troopExp"` // sum of the deployed troops' Exp
+	TowersDestroyed []string       `json:"towersDestroyed"`
+	TowerExp        int            `json:"towerExp"` // sum of the destroyed towers' Exp
+}
+
+// Reward is how a player's match reward was worked out.
+type Reward struct {
+	Outcome  string          `json:"outcome"`            // spec.OutcomeWin, OutcomeDraw or OutcomeLoss
+	Base     wallet.Balances `json:"base"`               // for the outcome, after sharing with teammates
+	Bonus    spec.Bonus      `json:"bonus"`              // performance EXP
+	Paid     wallet.Balances `json:"paid"`               // what reached the wallet
+	Unlocked []string        `json:"unlocked,omitempty"` // cards the payout unlocked
+	Chest    string          `json:"chest,omitempty"`    // chest type earned for a win
+	NoSlot   bool            `json:"noSlot,omitempty"`   // won, but every chest slot was full
+
+	Achievements []string `json:"achievements,omitempty"` // earned with this match
+
+	Arena      string  `json:"arena,omitempty"`      // where the player's trophies had them when the match started
+	Multiplier float64 `json:"multiplier,omitempty"` // the arena's, already applied to Base gold and EXP
+	Protected  bool    `json:"protected,omitempty"`  // the arena floor cut a trophy loss
+}
+
+// tally returns username's tally, starting one if needed. Caller must
+// hold gs.mu.
+func (gs *GameState) tally(username string) *Tally {
+	t, ok := gs.Tallies[username]
+	if !ok {
+		t = &Tally{Troops: make(map[string]int), TowersDestroyed: []string{}}
+		gs.Tallies[username] = t
+	}
+	return t
+}
+
+// recordDeploy adds a deploy and whatever it destroyed to the player's
+// tally. Caller must hold gs.mu.
+func (gs *GameState) recordDeploy(username string, troop *model.Troop, target *model.Tower, events []Event) {
+	t := gs.tally(username)
+	t.Deploys++
+	if t.Troops == nil {
+		t.Troops = make(map[string]int) // restored from a snapshot that predates it
+	}
+	t.Troops[troop.Name]++
+	t.TroopExp += troop.Exp
+	for _, e := range events {
+		if e.Type == EventTowerDestroyed {
+			t.TowersDestroyed = append(t.TowersDestroyed, target.Name)
+			t.TowerExp += target.Exp
+		}
+	}
+}
+
+// workOutRewards fills in gs.Rewards from the reward table and each
+// player's tally. Gold and EXP for the outcome are split between
+// teammates by contribution and scaled by each player's arena, trophies
+// are won or lost by everyone down to their arena floor, pass XP is
+// earned in full by everyone, and the performance bonus is each player's
+// own. Caller must hold gs.mu.
+func (gs *GameState) workOutRewards() {
+	perf := gs.Catalog.Performance()
+	gs.Rewards = make(map[string]*Reward, len(gs.Players))
+	for side := range gs.Towers {
+		outcome := spec.OutcomeDraw
+		switch gs.WinnerSide {
+		case side:
+			outcome = spec.OutcomeWin
+		case 1 - side:
+			outcome = spec.OutcomeLoss
+		}
+		table := gs.Catalog.Reward(outcome)
+		members := gs.Members(side)
+		for _, p := range members {
+			t := gs.tally(p.Username)
+			gs.Rewards[p.Username] = &Reward{
+				Outcome: outcome,
+				Base:    wallet.Balances{wallet.Trophies: table[wallet.Trophies], wallet.PassXP: table[wallet.PassXP]},
+				Bonus:   perf.Bonus(t.TowerExp, t.TroopExp, gs.Damage[p.Username]),
+			}
+		}
+		for _, c := range []wallet.Currency{wallet.Gold, wallet.Exp} {
+			for name, amt := range splitReward(table[c]*len(members), members, gs.Damage) {
+				gs.Rewards[name].Base[c] = amt
+			}
+		}
+		for _, p := range members {
+			gs.applyArena(p, gs.Rewards[p.Username])
+		}
+	}
+}
+
+// applyArena scales r's gold and EXP by the arena p's trophies had them
+// in when the match started, and stops a trophy loss taking them below
+// its floor. Caller must hold gs.mu.
+func (gs *GameState) applyArena(p *model.Player, r *Reward) {
+	trophies := p.Wallet[wallet.Trophies]
+	arena, _ := gs.Catalog.ArenaFor(trophies)
+	r.Arena, r.Multiplier = arena.ID, arena.Multiplier
+	for _, c := range []wallet.Currency{wallet.Gold, wallet.Exp} {
+		r.Base[c] = int(math.Round(float64(r.Base[c]) * arena.Multiplier))
+	}
+	if floor := gs.Catalog.ArenaFloor(trophies); trophies+r.Base[wallet.Trophies] < floor {
+		r.Base[wallet.Trophies] = floor - trophies
+		r.Protected = true
+	}
+}
+
+// summary is what username did this match, for lifetime stats. Caller must hold gs.mu.
+func (gs *GameState) summary(username string) model.MatchSummary {
+	t := gs.tally(username)
+	return model.MatchSummary{
+		Outcome:   gs.Rewards[username].Outcome,
+		Deploys:   t.Troops,
+		Destroyed: t.TowersDestroyed,
+		Damage:    gs.Damage[username],
+	}
+}
+
+// due is everything r pays.
+func (r *Reward) due() wallet.Balances {
+	out := make(wallet.Balances, len(r.Base))
+	for c, amt := range r.Base {
+		out[c] = amt
+	}
+	out[wallet.Exp] += r.Bonus.Total
+	return out
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"sort"
+	"strings"
+
+	"clashroyale/internal/model"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/upgrade"
+)
+
+// Side returns the side (0 or 1) username plays on, -1 if they aren't playing.
+func (gs *GameState) Side(username string) int {
+	if i := gs.PlayerIndex(username); i >= 0 {
+		return gs.Sides[i]
+	}
+	return -1
+}
+
+// Members returns the players on one side.
+func (gs *GameState) Members(side int) []*model.Player {
+	var out []*model.Player
+	for i, p := range gs.Players {
+		if gs.Sides[i] == side {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// sideName is how a side is shown as a winner: the player's name in
+// 1v1, "A & B" in team games.
+func (gs *GameState) sideName(side int) string {
+	var names []string
+	for _, p := range gs.Members(side) {
+		names = append(names, p.Username)
+	}
+	return strings.Join(names, " & ")
+}
+
+// setWinner records side as the winner. Caller must hold gs.mu.
+func (gs *GameState) setWinner(side int) {
+	gs.WinnerSide = side
+	gs.Winner = gs.sideName(side)
+}
+
+// sideTowers builds the towers a side defends. A solo player brings their
+// own upgraded towers; teammates share one set levelled to the average of
+// their tower levels.
+func sideTowers(cat *spec.Catalog, members []*model.Player) []*model.Tower {
+	if len(members) == 1 {
+		return cloneTowers(members[0].Towers)
+	}
+
+	towers := cat.Towers()
+	for _, t := range towers {
+		total := 0
+		for _, p := range members {
+			total += p.TowerLevels[t.Name]
+		}
+		upgrade.LevelTower(cat, t, total/len(members))
+	}
+	return towers
+}
+
+// splitReward shares a side's reward pool between its members: half evenly,
+// half by the damage each one dealt. Rounding leftovers go to the top
+// damage dealer so the pool is always paid out in full.
+func splitReward(pool int, members []*model.Player, damage map[string]int) map[string]int {
+	out := make(map[string]int, len(members))
+	if len(members) == 0 || pool <= 0 {
+		for _, p := range members {
+			out[p.Username] = 0
+		}
+		return out
+	}
+
+	totalDmg := 0
+	for _, p := range members {
+		totalDmg += damage[p.Username]
+	}
+
+	even := pool / 2
+	byDamage := pool - even
+	paid := 0
+	for _, p := range members {
+		share := even / len(members)
+		if totalDmg > 0 {
+			share += byDamage * damage[p.Username] / totalDmg
+		} else {
+			share += byDamage / len(members)
+		}
+		out[p.Username] = share
+		paid += share
+	}
+
+	ranked := append([]*model.Player(nil), members...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return damage[ranked[i].Username] > damage[ranked[j].Username]
+	})
+	out[ranked[0].Username] += pool - paid
+	return out
+}
@@ -0,0 +1,169 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the locale every other locale falls back to.
+const Default = "en"
+
+// Dir is where the locale files (<locale>.json) live.
+var Dir = "locales"
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]map[string]string{}
+)
+
+// Load reads every <locale>.json in dir into the message catalog.
+// Each file is a flat object mapping message keys to text. Text may
+// contain {name} placeholders that T fills in.
+func Load(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]map[string]string, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var msgs map[string]string
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		loaded[strings.TrimSuffix(filepath.Base(p), ".json")] = msgs
+	}
+	if _, ok := loaded[Default]; !ok {
+		return fmt.Errorf("%s: missing default locale %s.json", dir, Default)
+	}
+
+	mu.Lock()
+	catalogs = loaded
+	mu.Unlock()
+	return nil
+}
+
+// Locales lists the loaded locales, sorted.
+func Locales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Supported reports whether locale has a catalog.
+func Supported(locale string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Negotiate picks a locale: the profile preference if we have it,
+// otherwise the best match from an Accept-Language header, otherwise Default.
+func Negotiate(preferred, acceptLanguage string) string {
+	if preferred != "" && Supported(preferred) {
+		return preferred
+	}
+
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		tags = append(tags, tag{lang, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if t.q <= 0 {
+			continue
+		}
+		// "vi-VN" matches a "vi" catalog
+		base, _, _ := strings.Cut(t.lang, "-")
+		if Supported(t.lang) {
+			return t.lang
+		}
+		if Supported(base) {
+			return base
+		}
+	}
+	return Default
+}
+
+// Translator renders messages for one locale.
+type Translator struct {
+	Locale string
+}
+
+// For returns a Translator for locale, falling back to Default if it
+// isn't loaded.
+func For(locale string) *Translator {
+	if !Supported(locale) {
+		locale = Default
+	}
+	return &Translator{Locale: locale}
+}
+
+// T looks up key and fills {name} placeholders from alternating
+// name/value arguments, e.g. T("event.destroyed", "tower", "King Tower").
+// Missing keys fall back to the default locale, then to the key itself.
+func (t *Translator) T(key string, args ...any) string {
+	msg, ok := t.lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Has reports whether key exists in this locale or the default one.
+func (t *Translator) Has(key string) bool {
+	_, ok := t.lookup(key)
+	return ok
+}
+
+func (t *Translator) lookup(key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if msg, ok := catalogs[t.Locale][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[Default][key]
+	return msg, ok
+}
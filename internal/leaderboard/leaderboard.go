@@ -0,0 +1,94 @@
+package leaderboard
+
+import (
+	"sort"
+	"sync"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/wallet"
+)
+
+// Entry is a player's place on the trophy leaderboard.
+type Entry struct {
+	Rank     int    `json:"rank"`
+	Username string `json:"username"`
+	Trophies int    `json:"trophies"`
+}
+
+// board holds every player's trophies. It's built from the accounts at
+// startup and kept current by Track.
+var board = struct {
+	mu       sync.RWMutex
+	trophies map[string]int
+}{trophies: make(map[string]int)}
+
+// Load fills the leaderboard from every account.
+func Load() error {
+	users, err := auth.ListUsers()
+	if err != nil {
+		return err
+	}
+	board.mu.Lock()
+	defer board.mu.Unlock()
+	for _, u := range users {
+		board.trophies[u.Username] = u.Wallet[wallet.Trophies]
+	}
+	return nil
+}
+
+// Track updates u's place after a transaction, see auth.OnTransact.
+func Track(u *auth.User) {
+	Set(u.Username, u.Wallet[wallet.Trophies])
+}
+
+// Set records username's trophies.
+func Set(username string, trophies int) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+	board.trophies[username] = trophies
+}
+
+// ahead reports whether a places above b: more trophies, or the same
+// and alphabetically first.
+func ahead(a string, at int, b string, bt int) bool {
+	return at > bt || (at == bt && a < b)
+}
+
+// Rank returns username's place, 1 for the most trophies, or 0 if they
+// aren't on the board.
+func Rank(username string) int {
+	board.mu.RLock()
+	defer board.mu.RUnlock()
+	mine, ok := board.trophies[username]
+	if !ok {
+		return 0
+	}
+	rank := 1
+	for name, t := range board.trophies {
+		if ahead(name, t, username, mine) {
+			rank++
+		}
+	}
+	return rank
+}
+
+// Top returns the first n places, or every place if n is 0.
+func Top(n int) []Entry {
+	board.mu.RLock()
+	out := make([]Entry, 0, len(board.trophies))
+	for name, t := range board.trophies {
+		out = append(out, Entry{Username: name, Trophies: t})
+	}
+	board.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return ahead(out[i].Username, out[i].Trophies, out[j].Username, out[j].Trophies)
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
@@ -1,80 +1,171 @@
 package lobby
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Match is a game the lobby has put together: who plays, and on which
+// side. 1v1 games have one player per side, 2v2 games two.
+type Match struct {
+	Players []string
+	Sides   []int // side (0 or 1) of each player, parallel to Players
+	Options Options
+}
+
+// Has reports whether username plays in the match.
+func (mt *Match) Has(username string) bool {
+	for _, p := range mt.Players {
+		if p == username {
+			return true
+		}
+	}
+	return false
+}
+
+// newMatch lays out two teams as one match.
+func newMatch(side0, side1 []string, opts Options) *Match {
+	mt := &Match{Options: opts}
+	for side, team := range [][]string{side0, side1} {
+		for _, u := range team {
+			mt.Players = append(mt.Players, u)
+			mt.Sides = append(mt.Sides, side)
+		}
+	}
+	return mt
+}
+
+// Gate decides whether a player may enter a queue or room, e.g. to keep
+// out suspended accounts. It returns why not, or nil.
+type Gate func(username string) error
+
+// Tier places a player for matchmaking, e.g. by arena. The 1v1 queue
+// pairs players in the same tier first, see TierWiden.
+type Tier func(username string) int
+
 type Manager struct {
-	queue []string
-	games map[string][2]string
-	mu    sync.Mutex
+	gate      Gate
+	tier      Tier
+	queue     []queueEntry
+	teamQueue []party           // 2v2 queue, see teams.go
+	invites   map[string]invite // 2v2 party invites by who sent them, see teams.go
+	games     map[string]*Match
+	rooms     map[string]*Room // private rooms by code
+	timedOut  map[string]bool  // players dropped from the queue, until they next ask
+	waits     []time.Duration  // recent queue waits, for the estimate
+	closed    bool             // shutting down, see shutdown.go
+	mu        sync.Mutex
 }
 
-func NewManager() *Manager {
+// NewManager returns an empty lobby. gate is checked before anyone
+// queues or joins a room; nil lets everyone in. tier places players in
+// the 1v1 queue; nil puts everyone in the same tier.
+func NewManager(gate Gate, tier Tier) *Manager {
+	if gate == nil {
+		gate = func(string) error { return nil }
+	}
+	if tier == nil {
+		tier = func(string) int { return 0 }
+	}
 	return &Manager{
-		queue: make([]string, 0),
-		games: make(map[string][2]string),
+		gate:      gate,
+		tier:      tier,
+		queue:     make([]queueEntry, 0),
+		teamQueue: make([]party, 0),
+		invites:   make(map[string]invite),
+		games:     make(map[string]*Match),
+		rooms:     make(map[string]*Room),
+		timedOut:  make(map[string]bool),
 	}
 }
 
-func (m *Manager) Join(username string) string {
+// Join queues username for a 1v1 match. It returns the game ID once
+// they're paired, or an error if the gate turns them away.
+func (m *Manager) Join(username string) (string, error) {
+	if err := m.gate(username); err != nil {
+		return "", err
+	}
+	tier := m.tier(username)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	//already in game
-	for id, ps := range m.games {
-		if ps[0] == username || ps[1] == username {
-			return id
-		}
+	if id := m.gameOf(username); id != "" {
+		return id, nil
+	}
+	if m.closed {
+		return "", ErrClosed
 	}
 
+	// drop ghosts before anyone gets paired with them
+	now := time.Now()
+	m.purgeQueue(now)
+	delete(m.timedOut, username)
+
 	//already in queue
-	for _, u := range m.queue {
-		if u == username {
-			return ""
+	for i, e := range m.queue {
+		if e.Username == username {
+			m.queue[i].LastSeen = now
+			return "", nil
 		}
 	}
 
+	// can't wait in both queues at once
+	m.dequeue(username)
+
 	//enqueue
-	m.queue = append(m.queue, username)
+	m.queue = append(m.queue, queueEntry{Username: username, Tier: tier, JoinedAt: now, LastSeen: now})
 
-	// if 2+, pair them
-	if len(m.queue) >= 2 {
-		p1, p2 := m.queue[0], m.queue[1]
-		m.queue = m.queue[2:]
-		id := uuid.NewString()
-		m.games[id] = [2]string{p1, p2}
-		return id
-	}
-	return ""
+	m.matchQueue(now)
+	return m.gameOf(username), nil
 }
 
 // GetGame
 func (m *Manager) GetGame(username string) string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.gameOf(username)
+}
 
-	for id, ps := range m.games {
-		if ps[0] == username || ps[1] == username {
-			return id
-		}
+// GetMatch returns a copy of the match for gameID.
+func (m *Manager) GetMatch(gameID string) (Match, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mt, ok := m.games[gameID]
+	if !ok {
+		return Match{}, false
 	}
-	return ""
+	c := *mt
+	c.Players = append([]string(nil), mt.Players...)
+	c.Sides = append([]int(nil), mt.Sides...)
+	return c, true
 }
 
-// GetPlayers
-func (m *Manager) GetPlayers(gameID string) [2]string {
+// Matches returns a copy of every game the lobby has set up, by ID.
+func (m *Manager) Matches() map[string]Match {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.games[gameID]
+
+	out := make(map[string]Match, len(m.games))
+	for id, mt := range m.games {
+		c := *mt
+		c.Players = append([]string(nil), mt.Players...)
+		c.Sides = append([]int(nil), mt.Sides...)
+		out[id] = c
+	}
+	return out
 }
 
 // QueueLength
 func (m *Manager) QueueLength() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.purgeQueue(time.Now())
 	return len(m.queue)
 }
 
@@ -84,3 +175,67 @@ func (m *Manager) RemoveGame(gameID string) {
 	defer m.mu.Unlock()
 	delete(m.games, gameID)
 }
+
+// BarredError is returned when the gate turns a player away from a
+// match. It matches ErrBarred and the gate's reason with errors.Is.
+type BarredError struct {
+	Player string
+	Err    error // the gate's reason
+}
+
+func (e *BarredError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", ErrBarred, e.Player, e.Err)
+}
+
+func (e *BarredError) Unwrap() []error {
+	return []error{ErrBarred, e.Err}
+}
+
+// StartGame registers a match directly, skipping the queue (used for
+// rematches and tournaments). Its players leave the queue if they were
+// in it. A player the gate turns away fails it with a *BarredError.
+func (m *Manager) StartGame(mt Match) (string, error) {
+	if err := mt.Options.validate(); err != nil {
+		return "", err
+	}
+	for _, p := range mt.Players {
+		if err := m.gate(p); err != nil {
+			return "", &BarredError{Player: p, Err: err}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return "", ErrClosed
+	}
+	for _, p := range mt.Players {
+		if m.inGame(p) {
+			return "", ErrAlreadyInGame
+		}
+	}
+	for _, p := range mt.Players {
+		m.dequeue(p)
+	}
+
+	id := uuid.NewString()
+	m.games[id] = &mt
+	matchesCreated.WithLabelValues(sourceDirect).Inc()
+	return id, nil
+}
+
+// gameOf returns the game username is playing in. Caller must hold m.mu.
+func (m *Manager) gameOf(username string) string {
+	for id, mt := range m.games {
+		if mt.Has(username) {
+			return id
+		}
+	}
+	return ""
+}
+
+// inGame reports whether username is in an active game. Caller must hold m.mu.
+func (m *Manager) inGame(username string) bool {
+	return m.gameOf(username) != ""
+}
@@ -0,0 +1,90 @@
+package lobby
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	queueWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clashroyale_lobby_queue_wait_seconds",
+		Help:    "How long players waited in a public queue before being matched.",
+		Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"queue"})
+
+	matchesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clashroyale_lobby_matches_created_total",
+		Help: "Matches put together by the lobby, by where they came from.",
+	}, []string{"source"})
+
+	queueTimeouts = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clashroyale_lobby_queue_timeouts_total",
+		Help: "Players dropped from a queue for missing heartbeats or waiting too long.",
+	})
+)
+
+// Match sources for clashroyale_lobby_matches_created_total.
+const (
+	sourceQueue1v1 = Queue1v1
+	sourceQueue2v2 = Queue2v2
+	sourceRoom     = "room"
+	sourceDirect   = "direct" // StartGame: rematches and tournaments
+)
+
+// Collector reports the manager's live queue, room and game counts at
+// scrape time. Register it once per Manager.
+func (m *Manager) Collector() prometheus.Collector {
+	return &statsCollector{m}
+}
+
+var (
+	queueLengthDesc = prometheus.NewDesc("clashroyale_lobby_queue_length",
+		"Players waiting in each public queue.", []string{"queue"}, nil)
+	roomsDesc = prometheus.NewDesc("clashroyale_lobby_rooms",
+		"Open private rooms.", nil, nil)
+	gamesDesc = prometheus.NewDesc("clashroyale_lobby_games",
+		"Games the lobby has set up that haven't finished.", nil, nil)
+)
+
+type statsCollector struct {
+	m *Manager
+}
+
+func (s *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueLengthDesc
+	ch <- roomsDesc
+	ch <- gamesDesc
+}
+
+// Collect only reads: players and rooms past their time still count as
+// gone, but dropping them is left to the next lobby request.
+func (s *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	m := s.m
+	now := time.Now()
+	m.mu.Lock()
+	solo, teams, rooms := 0, 0, 0
+	for _, e := range m.queue {
+		if !e.stale(now) {
+			solo++
+		}
+	}
+	for _, p := range m.teamQueue {
+		if !p.stale(now) {
+			teams += len(p.members)
+		}
+	}
+	for _, r := range m.rooms {
+		if !now.After(r.ExpiresAt) {
+			rooms++
+		}
+	}
+	games := len(m.games)
+	m.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(queueLengthDesc, prometheus.GaugeValue, float64(solo), Queue1v1)
+	ch <- prometheus.MustNewConstMetric(queueLengthDesc, prometheus.GaugeValue, float64(teams), Queue2v2)
+	ch <- prometheus.MustNewConstMetric(roomsDesc, prometheus.GaugeValue, float64(rooms))
+	ch <- prometheus.MustNewConstMetric(gamesDesc, prometheus.GaugeValue, float64(games))
+}
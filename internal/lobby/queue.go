@@ -0,0 +1,271 @@
+package lobby
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PresenceTimeout is how long a queued player can go without a
+	// heartbeat (the wait page polls /lobby/status every second) before
+	// they're treated as gone and dropped from the queue.
+	PresenceTimeout = 10 * time.Second
+
+	// MaxQueueTime is the longest anyone waits before being sent back
+	// to the lobby.
+	MaxQueueTime = 5 * time.Minute
+
+	// waitSamples is how many recent match waits feed the estimate.
+	waitSamples = 20
+
+	// TierWiden is how long it takes for the 1v1 queue to look one tier
+	// further for an opponent. Players start out matched only within
+	// their own tier.
+	TierWiden = 10 * time.Second
+)
+
+// queueEntry is one player waiting for a public match.
+type queueEntry struct {
+	Username string
+	Tier     int
+	JoinedAt time.Time
+	LastSeen time.Time
+}
+
+// stale reports whether the player has stopped polling or waited too long
+// by now.
+func (e queueEntry) stale(now time.Time) bool {
+	return now.Sub(e.LastSeen) > PresenceTimeout || now.Sub(e.JoinedAt) > MaxQueueTime
+}
+
+// QueueStatus is what a player sees while waiting.
+type QueueStatus struct {
+	GameID        string `json:"gameID"`
+	InQueue       bool   `json:"inQueue"`
+	Queue         string `json:"queue,omitempty"`      // Queue1v1 or Queue2v2
+	WaitingFor    string `json:"waitingFor,omitempty"` // 2v2 partner who hasn't accepted yet
+	Position      int    `json:"position"`             // 1-based, 0 when not queued
+	QueueLength   int    `json:"queueLength"`
+	Waited        int    `json:"waitedSeconds"`
+	EstimatedWait int    `json:"estimatedWaitSeconds"` // -1 when we have no data yet
+	TimedOut      bool   `json:"timedOut"`             // dropped for MaxQueueTime or missed heartbeats
+	Closed        bool   `json:"closed"`               // the server is shutting down, nobody is queued
+}
+
+// Leave removes username from the queue. It reports whether they were queued.
+func (m *Manager) Leave(username string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dequeue(username)
+}
+
+// dequeue removes username from both queues and drops the party invite
+// they sent, if any. In the 2v2 queue their whole party leaves with them.
+// Caller must hold m.mu.
+func (m *Manager) dequeue(username string) bool {
+	_, invited := m.invites[username]
+	delete(m.invites, username)
+	for i, e := range m.queue {
+		if e.Username == username {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return true
+		}
+	}
+	for i, p := range m.teamQueue {
+		if p.has(username) {
+			m.teamQueue = append(m.teamQueue[:i], m.teamQueue[i+1:]...)
+			return true
+		}
+	}
+	return invited
+}
+
+// Heartbeat records that username is still waiting.
+func (m *Manager) Heartbeat(username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for i := range m.queue {
+		if m.queue[i].Username == username {
+			m.queue[i].LastSeen = now
+			return
+		}
+	}
+	for _, p := range m.teamQueue {
+		for i := range p.members {
+			if p.members[i].Username == username {
+				p.members[i].LastSeen = now
+				return
+			}
+		}
+	}
+}
+
+// Status reports username's place in the queue, or the game they were
+// matched into.
+func (m *Manager) Status(username string) QueueStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.purgeQueue(now)
+	m.matchQueue(now)
+
+	st := QueueStatus{QueueLength: len(m.queue), EstimatedWait: -1}
+	if id := m.gameOf(username); id != "" {
+		st.GameID = id
+		return st
+	}
+	if m.closed {
+		st.Closed = true
+		return st
+	}
+	if m.timedOut[username] {
+		delete(m.timedOut, username)
+		st.TimedOut = true
+		return st
+	}
+
+	for i, e := range m.queue {
+		if e.Username == username {
+			st.InQueue = true
+			st.Queue = Queue1v1
+			st.Position = i + 1
+			st.Waited = int(now.Sub(e.JoinedAt).Seconds())
+			break
+		}
+	}
+	if !st.InQueue {
+		m.teamStatus(username, now, &st)
+	}
+	if st.InQueue && len(m.waits) > 0 {
+		var total time.Duration
+		for _, w := range m.waits {
+			total += w
+		}
+		avg := total / time.Duration(len(m.waits))
+		st.EstimatedWait = max(int((avg - time.Duration(st.Waited)*time.Second).Seconds()), 0)
+	}
+	return st
+}
+
+// matchQueue pairs up the 1v1 queue, longest waiting first. Each player
+// gets the opponent closest to their tier among those in reach; reach
+// starts at their own tier and grows by one every TierWiden that the
+// longer waiting of the two has queued. Caller must hold m.mu.
+func (m *Manager) matchQueue(now time.Time) {
+	gap := func(a, b queueEntry) int {
+		if a.Tier > b.Tier {
+			return a.Tier - b.Tier
+		}
+		return b.Tier - a.Tier
+	}
+	for i := 0; i < len(m.queue); i++ {
+		p1 := m.queue[i]
+		reach := int(now.Sub(p1.JoinedAt) / TierWiden) // the queue is in join order
+		best := -1
+		for j := i + 1; j < len(m.queue); j++ {
+			if g := gap(p1, m.queue[j]); g <= reach && (best < 0 || g < gap(p1, m.queue[best])) {
+				best = j
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		p2 := m.queue[best]
+		m.queue = append(m.queue[:best], m.queue[best+1:]...)
+		m.queue = append(m.queue[:i], m.queue[i+1:]...)
+		i-- // the next player has moved into i
+
+		m.recordWait(Queue1v1, now.Sub(p1.JoinedAt))
+		m.recordWait(Queue1v1, now.Sub(p2.JoinedAt))
+		id := uuid.NewString()
+		m.games[id] = newMatch([]string{p1.Username}, []string{p2.Username}, defaultOptions())
+		matchesCreated.WithLabelValues(sourceQueue1v1).Inc()
+	}
+}
+
+// purgeQueue drops players who stopped polling or waited too long, and
+// party invites nobody accepted within InviteTTL. Caller must hold m.mu.
+func (m *Manager) purgeQueue(now time.Time) {
+	kept := m.queue[:0]
+	for _, e := range m.queue {
+		if e.stale(now) {
+			m.timedOut[e.Username] = true
+			queueTimeouts.Inc()
+			continue
+		}
+		kept = append(kept, e)
+	}
+	m.queue = kept
+
+	keptParties := m.teamQueue[:0]
+	for _, p := range m.teamQueue {
+		if p.stale(now) {
+			for _, e := range p.members {
+				m.timedOut[e.Username] = true
+				queueTimeouts.Inc()
+			}
+			continue
+		}
+		keptParties = append(keptParties, p)
+	}
+	m.teamQueue = keptParties
+
+	for username, inv := range m.invites {
+		if now.Sub(inv.SentAt) > InviteTTL {
+			delete(m.invites, username)
+			m.timedOut[username] = true
+		}
+	}
+}
+
+// recordWait adds a completed wait in queue to the estimate window.
+// Caller must hold m.mu.
+func (m *Manager) recordWait(queue string, d time.Duration) {
+	queueWaitSeconds.WithLabelValues(queue).Observe(d.Seconds())
+	m.waits = append(m.waits, d)
+	if len(m.waits) > waitSamples {
+		m.waits = m.waits[len(m.waits)-waitSamples:]
+	}
+}
+
+// Waiting is one queue entry as seen by an operator.
+type Waiting struct {
+	Players []string `json:"players"` // one name, or a 2v2 party
+	Queue   string   `json:"queue"`
+	Waited  int      `json:"waitedSeconds"`
+}
+
+// Overview is everything the lobby is holding, for the admin console.
+type Overview struct {
+	Waiting []Waiting `json:"waiting"`
+	Invites int       `json:"invites"` // 2v2 partner invites not yet accepted
+	Rooms   []Room    `json:"rooms"`
+	Games   int       `json:"games"`
+}
+
+// Overview returns a snapshot of both queues, open rooms and the number
+// of games in progress.
+func (m *Manager) Overview() Overview {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.purgeQueue(now)
+	m.purgeRooms()
+
+	ov := Overview{Waiting: []Waiting{}, Invites: len(m.invites), Rooms: []Room{}, Games: len(m.games)}
+	for _, e := range m.queue {
+		ov.Waiting = append(ov.Waiting, Waiting{[]string{e.Username}, Queue1v1, int(now.Sub(e.JoinedAt).Seconds())})
+	}
+	for _, p := range m.teamQueue {
+		ov.Waiting = append(ov.Waiting, Waiting{p.names(), Queue2v2, int(now.Sub(p.members[0].JoinedAt).Seconds())})
+	}
+	for _, r := range m.rooms {
+		ov.Rooms = append(ov.Rooms, r.copy())
+	}
+	return ov
+}
@@ -0,0 +1,292 @@
+package lobby
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Game modes a private room can pick.
+const (
+	ModeClassic    = "classic"     // normal mana regen
+	ModeDoubleMana = "double_mana" // mana regenerates twice as fast
+)
+
+const (
+	// RoomTTL is how long a private room stays open.
+	RoomTTL = 10 * time.Minute
+
+	// Match duration bounds for private rooms.
+	MinDuration     = time.Minute
+	MaxDuration     = 10 * time.Minute
+	DefaultDuration = 3 * time.Minute
+
+	codeLen = 6
+	// no 0/O or 1/I so codes are easy to read out loud
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrRoomNotFound   = errors.New("room not found")
+	ErrRoomExpired    = errors.New("room has expired")
+	ErrRoomFull       = errors.New("room is full")
+	ErrNotInRoom      = errors.New("you are not in this room")
+	ErrAlreadyInGame  = errors.New("already in a game")
+	ErrBarred         = errors.New("player turned away by the gate")
+	ErrInvalidOptions = errors.New("invalid room options")
+)
+
+// Options are the match settings a room host can choose.
+type Options struct {
+	Duration time.Duration `json:"duration"`
+	Mode     string        `json:"mode"`
+}
+
+// Room is a private match between a host and one invited guest.
+type Room struct {
+	Code      string          `json:"code"`
+	Host      string          `json:"host"`
+	Guest     string          `json:"guest"`
+	Options   Options         `json:"options"`
+	Ready     map[string]bool `json:"ready"`
+	GameID    string          `json:"gameID"` // set once both players are ready
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// validate fills in defaults and checks the options are in range.
+func (o *Options) validate() error {
+	if o.Duration == 0 {
+		o.Duration = DefaultDuration
+	}
+	if o.Mode == "" {
+		o.Mode = ModeClassic
+	}
+	if o.Duration < MinDuration || o.Duration > MaxDuration {
+		return ErrInvalidOptions
+	}
+	if o.Mode != ModeClassic && o.Mode != ModeDoubleMana {
+		return ErrInvalidOptions
+	}
+	return nil
+}
+
+// newCode returns a random room code.
+func newCode() string {
+	b := make([]byte, codeLen)
+	rand.Read(b)
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
+
+// CreateRoom opens a private room hosted by host. A host can only have
+// one open room, so any previous one is closed.
+func (m *Manager) CreateRoom(host string, opts Options) (Room, error) {
+	if err := opts.validate(); err != nil {
+		return Room{}, err
+	}
+	if err := m.gate(host); err != nil {
+		return Room{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purgeRooms()
+
+	if m.closed {
+		return Room{}, ErrClosed
+	}
+	if m.inGame(host) {
+		return Room{}, ErrAlreadyInGame
+	}
+	for code, r := range m.rooms {
+		if r.Host == host && r.GameID == "" {
+			delete(m.rooms, code)
+		}
+	}
+	// playing a friend instead of waiting for a stranger
+	m.dequeue(host)
+
+	code := newCode()
+	for m.rooms[code] != nil {
+		code = newCode()
+	}
+	r := &Room{
+		Code:      code,
+		Host:      host,
+		Options:   opts,
+		Ready:     map[string]bool{host: false},
+		ExpiresAt: time.Now().Add(RoomTTL),
+	}
+	m.rooms[code] = r
+	return r.copy(), nil
+}
+
+// JoinRoom seats username as the room's guest. Joining a room you are
+// already in is a no-op.
+func (m *Manager) JoinRoom(code, username string) (Room, error) {
+	if err := m.gate(username); err != nil {
+		return Room{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.room(code)
+	if err != nil {
+		return Room{}, err
+	}
+	if r.Host == username || r.Guest == username {
+		return r.copy(), nil
+	}
+	if m.closed {
+		return Room{}, ErrClosed
+	}
+	if r.Guest != "" {
+		return Room{}, ErrRoomFull
+	}
+	if m.inGame(username) {
+		return Room{}, ErrAlreadyInGame
+	}
+	r.Guest = username
+	r.Ready[username] = false
+	m.dequeue(username)
+	return r.copy(), nil
+}
+
+// SetReady marks a room member ready or not. When both players are ready
+// the match is created and its ID recorded on the room, as long as the
+// gate still lets them both in and neither has gone into another game.
+func (m *Manager) SetReady(code, username string, ready bool) (Room, error) {
+	// the gate reads accounts, so run it for everyone in the room before
+	// taking the lock for good
+	if ready {
+		m.mu.Lock()
+		r, err := m.room(code)
+		var members []string
+		if err == nil {
+			members = []string{r.Host, r.Guest}
+		}
+		m.mu.Unlock()
+		for _, p := range members {
+			if p == "" {
+				continue
+			}
+			if err := m.gate(p); err != nil {
+				return Room{}, err
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.room(code)
+	if err != nil {
+		return Room{}, err
+	}
+	if r.Host != username && r.Guest != username {
+		return Room{}, ErrNotInRoom
+	}
+	if r.GameID != "" {
+		return r.copy(), nil
+	}
+	if m.closed {
+		return Room{}, ErrClosed
+	}
+	r.Ready[username] = ready
+
+	if r.Guest != "" && r.Ready[r.Host] && r.Ready[r.Guest] {
+		for _, p := range []string{r.Host, r.Guest} {
+			if m.inGame(p) {
+				r.Ready[username] = false
+				return Room{}, ErrAlreadyInGame
+			}
+		}
+		// queued since joining the room: the room wins
+		m.dequeue(r.Host)
+		m.dequeue(r.Guest)
+		id := uuid.NewString()
+		m.games[id] = newMatch([]string{r.Host}, []string{r.Guest}, r.Options)
+		matchesCreated.WithLabelValues(sourceRoom).Inc()
+		r.GameID = id
+	}
+	return r.copy(), nil
+}
+
+// LeaveRoom removes username from a room that hasn't started. If the
+// host leaves, the room is closed.
+func (m *Manager) LeaveRoom(code, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.room(code)
+	if err != nil {
+		return err
+	}
+	switch username {
+	case r.Host:
+		delete(m.rooms, code)
+	case r.Guest:
+		if r.GameID == "" {
+			r.Guest = ""
+			delete(r.Ready, username)
+			r.Ready[r.Host] = false
+		}
+	default:
+		return ErrNotInRoom
+	}
+	return nil
+}
+
+// GetRoom returns a snapshot of a room.
+func (m *Manager) GetRoom(code string) (Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.room(code)
+	if err != nil {
+		return Room{}, err
+	}
+	return r.copy(), nil
+}
+
+// defaultOptions are the options for games from the public queues.
+func defaultOptions() Options {
+	return Options{Duration: DefaultDuration, Mode: ModeClassic}
+}
+
+// room looks up a live room. Caller must hold m.mu.
+func (m *Manager) room(code string) (*Room, error) {
+	r, ok := m.rooms[code]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	if time.Now().After(r.ExpiresAt) {
+		delete(m.rooms, code)
+		return nil, ErrRoomExpired
+	}
+	return r, nil
+}
+
+// purgeRooms drops expired rooms. Caller must hold m.mu.
+func (m *Manager) purgeRooms() {
+	now := time.Now()
+	for code, r := range m.rooms {
+		if now.After(r.ExpiresAt) {
+			delete(m.rooms, code)
+		}
+	}
+}
+
+func (r *Room) copy() Room {
+	c := *r
+	c.Ready = make(map[string]bool, len(r.Ready))
+	for k, v := range r.Ready {
+		c.Ready[k] = v
+	}
+	return c
+}
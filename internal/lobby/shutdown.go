@@ -0,0 +1,39 @@
+package lobby
+
+import "errors"
+
+// ErrClosed is returned once the lobby stops taking players because the
+// server is shutting down.
+var ErrClosed = errors.New("the server is shutting down")
+
+// Close stops the lobby from putting anyone in a new game. Both queues,
+// party invites and rooms that haven't started are dropped; games already
+// set up are kept so they can finish or be saved.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.queue = m.queue[:0]
+	m.teamQueue = m.teamQueue[:0]
+	clear(m.invites)
+	for code, r := range m.rooms {
+		if r.GameID == "" {
+			delete(m.rooms, code)
+		}
+	}
+}
+
+// Closed reports whether Close has been called.
+func (m *Manager) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+// Restore puts back a game saved before a restart, under its old ID, so
+// its players find it again.
+func (m *Manager) Restore(gameID string, mt Match) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.games[gameID] = &mt
+}
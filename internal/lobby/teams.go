@@ -0,0 +1,189 @@
+package lobby
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Queue names reported in QueueStatus.Queue.
+const (
+	Queue1v1 = "1v1"
+	Queue2v2 = "2v2"
+)
+
+// TeamSize is the number of players per side in the 2v2 queue.
+const TeamSize = 2
+
+// InviteTTL is how long a player waits for their partner to accept a
+// 2v2 party invite before being sent back to the lobby.
+const InviteTTL = 2 * time.Minute
+
+// invite is a player waiting for a friend to queue with them.
+type invite struct {
+	Partner string
+	SentAt  time.Time
+}
+
+// party is one entry in the 2v2 queue: a solo player looking for a random
+// teammate, or two friends queueing together.
+type party struct {
+	members []queueEntry
+}
+
+// stale reports whether any member has gone stale by now: a party is only
+// as present as its least present member.
+func (p party) stale(now time.Time) bool {
+	for _, e := range p.members {
+		if e.stale(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p party) has(username string) bool {
+	for _, e := range p.members {
+		if e.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (p party) names() []string {
+	out := make([]string, len(p.members))
+	for i, e := range p.members {
+		out[i] = e.Username
+	}
+	return out
+}
+
+// JoinTeams queues username for a 2v2 match. With no partner they get a
+// random teammate. With a partner, both players have to name each other:
+// the first to ask waits, and the party is queued once the second one
+// does. It returns the game ID if this join completed a match.
+func (m *Manager) JoinTeams(username, partner string) (string, error) {
+	if err := m.gate(username); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id := m.gameOf(username); id != "" {
+		return id, nil
+	}
+	if m.closed {
+		return "", ErrClosed
+	}
+
+	now := time.Now()
+	m.purgeQueue(now)
+	delete(m.timedOut, username)
+
+	// switching queues or partners starts over
+	m.dequeue(username)
+
+	entry := func(u string) queueEntry {
+		return queueEntry{Username: u, JoinedAt: now, LastSeen: now}
+	}
+
+	switch {
+	case partner == "" || partner == username:
+		m.teamQueue = append(m.teamQueue, party{members: []queueEntry{entry(username)}})
+	case m.invites[partner].Partner == username:
+		// partner is already waiting for us: queue the pair
+		m.dequeue(partner)
+		m.teamQueue = append(m.teamQueue, party{members: []queueEntry{entry(partner), entry(username)}})
+	default:
+		m.invites[username] = invite{Partner: partner, SentAt: now}
+		return "", nil
+	}
+
+	if id := m.matchTeams(now); id != "" && m.games[id].Has(username) {
+		return id, nil
+	}
+	return "", nil
+}
+
+// matchTeams builds teams from the 2v2 queue in arrival order (a duo is a
+// team on its own, solos pair up) and starts a match as soon as two teams
+// exist. Caller must hold m.mu.
+func (m *Manager) matchTeams(now time.Time) string {
+	var teams [][]int // indices into m.teamQueue
+	solo := -1
+	for i, p := range m.teamQueue {
+		switch {
+		case len(p.members) == TeamSize:
+			teams = append(teams, []int{i})
+		case solo < 0:
+			solo = i
+		default:
+			teams = append(teams, []int{solo, i})
+			solo = -1
+		}
+		if len(teams) == 2 {
+			break
+		}
+	}
+	if len(teams) < 2 {
+		return ""
+	}
+
+	used := make(map[int]bool)
+	var sides [2][]string
+	for side, team := range teams {
+		for _, i := range team {
+			used[i] = true
+			sides[side] = append(sides[side], m.teamQueue[i].names()...)
+			for _, e := range m.teamQueue[i].members {
+				m.recordWait(Queue2v2, now.Sub(e.JoinedAt))
+			}
+		}
+	}
+
+	kept := m.teamQueue[:0]
+	for i, p := range m.teamQueue {
+		if !used[i] {
+			kept = append(kept, p)
+		}
+	}
+	m.teamQueue = kept
+
+	id := uuid.NewString()
+	m.games[id] = newMatch(sides[0], sides[1], defaultOptions())
+	matchesCreated.WithLabelValues(sourceQueue2v2).Inc()
+	return id
+}
+
+// teamStatus fills in st for a player in the 2v2 queue or waiting on a
+// partner. Caller must hold m.mu.
+func (m *Manager) teamStatus(username string, now time.Time, st *QueueStatus) {
+	if inv, ok := m.invites[username]; ok {
+		st.InQueue = true
+		st.Queue = Queue2v2
+		st.WaitingFor = inv.Partner
+		st.Waited = int(now.Sub(inv.SentAt).Seconds())
+		return
+	}
+
+	players := 0
+	for _, p := range m.teamQueue {
+		players += len(p.members)
+	}
+	for i, p := range m.teamQueue {
+		if p.has(username) {
+			st.InQueue = true
+			st.Queue = Queue2v2
+			st.Position = i + 1
+			st.QueueLength = players
+			for _, e := range p.members {
+				if e.Username == username {
+					st.Waited = int(now.Sub(e.JoinedAt).Seconds())
+				}
+			}
+			return
+		}
+	}
+}
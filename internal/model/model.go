@@ -1,13 +1,30 @@
 package model
 
+import "clashroyale/internal/wallet"
+
 // Player holds basic profile info (loaded from auth.User).
 type Player struct {
-	Username    string         `json:"username"`
-	Exp         int            `json:"exp"`
-	Level       int            `json:"level"`
-	Towers      []*Tower       `json:"towers"`
-	TroopLevels map[string]int `json:"troop_levels"` // Maps troop name to level
-	TowerLevels map[string]int `json:"tower_levels"` // Maps tower name to level
+	Username    string          `json:"username"`
+	Wallet      wallet.Balances `json:"wallet"`
+	Level       int             `json:"level"`
+	Towers      []*Tower        `json:"towers"`
+	TroopLevels map[string]int  `json:"troop_levels"` // Maps troop name to level
+	TowerLevels map[string]int  `json:"tower_levels"` // Maps tower name to level
+	Cards       []string        `json:"cards"`        // troops the player may draw
+}
+
+// Owns reports whether the player has unlocked the named troop. Players
+// saved before collections existed own every troop.
+func (p *Player) Owns(name string) bool {
+	if p.Cards == nil {
+		return true
+	}
+	for _, c := range p.Cards {
+		if c == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Troop represents one unit your player can deploy.
@@ -32,3 +49,11 @@ type Tower struct {
 	Exp   int     `json:"exp"`  // EXP awarded when this tower is destroyed
 	Level int     `json:"level"`
 }
+
+// MatchSummary is what one player did in a match, or in part of one.
+type MatchSummary struct {
+	Outcome   string         // "win", "draw" or "loss", empty until the match ends
+	Deploys   map[string]int // by troop
+	Destroyed []string       // tower names, once per tower destroyed
+	Damage    int            // dealt to towers
+}
@@ -0,0 +1,72 @@
+package pass
+
+import (
+	"errors"
+	"slices"
+
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+)
+
+var (
+	ErrNoSeason        = errors.New("no season under way")
+	ErrTierNotFound    = errors.New("battle pass tier not found")
+	ErrTierLocked      = errors.New("battle pass tier not reached")
+	ErrPremiumRequired = errors.New("premium battle pass required")
+	ErrAlreadyClaimed  = errors.New("battle pass tier already claimed")
+)
+
+// Pass is a player's battle pass for one season. Their progress is the
+// pass XP in their wallet, which is reset when the season ends.
+type Pass struct {
+	Season  string           `json:"season,omitempty"`
+	Premium bool             `json:"premium,omitempty"` // granted by an admin
+	Claimed map[string][]int `json:"claimed,omitempty"` // tiers claimed, by track
+}
+
+// Rotate starts a fresh pass, without premium or claims, if p belongs to
+// a season other than season. It reports whether p changed.
+func (p *Pass) Rotate(season string) bool {
+	if p.Season == season {
+		return false
+	}
+	*p = Pass{Season: season}
+	return true
+}
+
+// Has reports whether tier has been claimed on track.
+func (p Pass) Has(track string, tier int) bool {
+	return slices.Contains(p.Claimed[track], tier)
+}
+
+// Reward returns what claiming tier on track would pay a player holding
+// xp pass XP, without claiming it.
+func (p Pass) Reward(cat *spec.Catalog, track string, tier, xp int) (wallet.Balances, error) {
+	reward, ok := cat.PassReward(track, tier)
+	if !ok {
+		return nil, ErrTierNotFound
+	}
+	if tier > cat.PassTierAt(xp) {
+		return nil, ErrTierLocked
+	}
+	if track == spec.TrackPremium && !p.Premium {
+		return nil, ErrPremiumRequired
+	}
+	if p.Has(track, tier) {
+		return nil, ErrAlreadyClaimed
+	}
+	return reward, nil
+}
+
+// Claim marks tier claimed on track and returns its reward, see Reward.
+func (p *Pass) Claim(cat *spec.Catalog, track string, tier, xp int) (wallet.Balances, error) {
+	reward, err := p.Reward(cat, track, tier, xp)
+	if err != nil {
+		return nil, err
+	}
+	if p.Claimed == nil {
+		p.Claimed = make(map[string][]int)
+	}
+	p.Claimed[track] = append(p.Claimed[track], tier)
+	return reward, nil
+}
@@ -0,0 +1,154 @@
+package quest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"clashroyale/internal/model"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+)
+
+// Clock is where quest rotation reads the time. The server always runs
+// on the wall clock; tests and tools can swap in their own.
+var Clock = time.Now
+
+var (
+	ErrQuestNotFound  = errors.New("quest not found")
+	ErrNotComplete    = errors.New("quest not complete")
+	ErrAlreadyClaimed = errors.New("quest reward already claimed")
+)
+
+// Entry is a quest a player has been given for one day or week.
+type Entry struct {
+	ID       string `json:"id"`     // quest ID in quests.json
+	Period   string `json:"period"` // spec.PeriodDaily or PeriodWeekly
+	Key      string `json:"key"`    // the day or week it was given for, see Key
+	Progress int    `json:"progress"`
+	Claimed  bool   `json:"claimed,omitempty"`
+}
+
+// Board is a player's current quests.
+type Board []Entry
+
+// Key names the period containing now: the UTC date for daily quests,
+// the ISO week for weekly ones.
+func Key(period string, now time.Time) string {
+	now = now.UTC()
+	if period == spec.PeriodWeekly {
+		y, w := now.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	}
+	return now.Format("2006-01-02")
+}
+
+// Ends returns when the period containing now rotates: the next UTC
+// midnight for daily quests, the next Monday's for weekly ones.
+func Ends(period string, now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == spec.PeriodWeekly {
+		return day.AddDate(0, 0, 7-(int(day.Weekday())+6)%7)
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+// Rotate drops quests from past periods, claimed or not, and deals a new
+// set for any period the board has none for. It reports whether the
+// board changed.
+func (b *Board) Rotate(cat *spec.Catalog, now time.Time) bool {
+	changed := false
+	kept := (*b)[:0]
+	has := make(map[string]bool)
+	for _, e := range *b {
+		if e.Key != Key(e.Period, now) {
+			changed = true
+			continue
+		}
+		kept = append(kept, e)
+		has[e.Period] = true
+	}
+	*b = kept
+
+	for _, period := range []string{spec.PeriodDaily, spec.PeriodWeekly} {
+		if has[period] {
+			continue
+		}
+		pool, n := cat.QuestPool(period)
+		for _, i := range rand.Perm(len(pool))[:n] {
+			*b = append(*b, Entry{ID: pool[i].ID, Period: period, Key: Key(period, now)})
+			changed = true
+		}
+	}
+	return changed
+}
+
+// count is how far m takes quest q.
+func count(m model.MatchSummary, q spec.Quest) int {
+	n := 0
+	switch q.Kind {
+	case spec.QuestDeploy:
+		for troop, d := range m.Deploys {
+			if q.Target == "" || troop == q.Target {
+				n += d
+			}
+		}
+	case spec.QuestDestroy:
+		for _, tower := range m.Destroyed {
+			if q.Target == "" || tower == q.Target {
+				n++
+			}
+		}
+	case spec.QuestWin:
+		if m.Outcome == spec.OutcomeWin {
+			n = 1
+		}
+	case spec.QuestPlay:
+		if m.Outcome != "" {
+			n = 1
+		}
+	case spec.QuestDamage:
+		n = m.Damage
+	}
+	return n
+}
+
+// Record adds m to the progress of every quest on the board, up to each
+// quest's count. Quests no longer in cat are left alone.
+func (b Board) Record(cat *spec.Catalog, m model.MatchSummary) {
+	for i, e := range b {
+		q, ok := cat.Quest(e.ID)
+		if !ok || e.Claimed {
+			continue
+		}
+		b[i].Progress = min(q.Count, e.Progress+count(m, q))
+	}
+}
+
+// Claim marks a completed quest claimed and returns its reward.
+func (b Board) Claim(cat *spec.Catalog, id string) (wallet.Balances, error) {
+	for i, e := range b {
+		if e.ID != id {
+			continue
+		}
+		q, ok := cat.Quest(id)
+		if !ok {
+			break
+		}
+		if e.Claimed {
+			return nil, ErrAlreadyClaimed
+		}
+		if e.Progress < q.Count {
+			return nil, ErrNotComplete
+		}
+		b[i].Claimed = true
+		reward := make(wallet.Balances, len(q.Reward))
+		for c, amt := range q.Reward {
+			reward[c] = amt
+		}
+		return reward, nil
+	}
+	return nil, ErrQuestNotFound
+}
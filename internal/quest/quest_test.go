@@ -0,0 +1,202 @@
+package quest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"clashroyale/internal/model"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+)
+
+// a Wednesday
+var t0 = time.Date(2026, 10, 14, 15, 30, 0, 0, time.UTC)
+
+func loadCatalog(t *testing.T) *spec.Catalog {
+	t.Helper()
+	cat, err := spec.Load("../../specs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cat
+}
+
+func TestPeriods(t *testing.T) {
+	tests := []struct {
+		name     string
+		period   string
+		now      time.Time
+		wantKey  string
+		wantEnds time.Time
+	}{
+		{"daily", spec.PeriodDaily, t0, "2026-10-14", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"daily at midnight", spec.PeriodDaily, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), "2026-10-15", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"daily in another zone", spec.PeriodDaily, time.Date(2026, 10, 14, 23, 0, 0, 0, time.FixedZone("UTC-3", -3*3600)), "2026-10-15", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"weekly midweek", spec.PeriodWeekly, t0, "2026-W42", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"weekly on Monday", spec.PeriodWeekly, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), "2026-W43", time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{"weekly on Sunday", spec.PeriodWeekly, time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), "2026-W42", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Key(tt.period, tt.now); got != tt.wantKey {
+				t.Errorf("Key() = %q, want %q", got, tt.wantKey)
+			}
+			if got := Ends(tt.period, tt.now); !got.Equal(tt.wantEnds) {
+				t.Errorf("Ends() = %v, want %v", got, tt.wantEnds)
+			}
+		})
+	}
+}
+
+// dealt returns how many of b's quests belong to period, and how many of
+// those were dealt for now.
+func dealt(b Board, period string, now time.Time) (all, current int) {
+	for _, e := range b {
+		if e.Period != period {
+			continue
+		}
+		all++
+		if e.Key == Key(period, now) {
+			current++
+		}
+	}
+	return all, current
+}
+
+func TestRotate(t *testing.T) {
+	cat := loadCatalog(t)
+	_, daily := cat.QuestPool(spec.PeriodDaily)
+	_, weekly := cat.QuestPool(spec.PeriodWeekly)
+
+	var b Board
+	if !b.Rotate(cat, t0) {
+		t.Fatal("Rotate() on an empty board reported no change")
+	}
+	if all, cur := dealt(b, spec.PeriodDaily, t0); all != daily || cur != daily {
+		t.Errorf("daily quests = %d (%d current), want %d", all, cur, daily)
+	}
+	if all, cur := dealt(b, spec.PeriodWeekly, t0); all != weekly || cur != weekly {
+		t.Errorf("weekly quests = %d (%d current), want %d", all, cur, weekly)
+	}
+	seen := make(map[string]bool)
+	for _, e := range b {
+		if seen[e.ID] {
+			t.Errorf("quest %s dealt twice", e.ID)
+		}
+		seen[e.ID] = true
+	}
+
+	// progress survives a rotation within the same period
+	b[0].Progress = 1
+	if b.Rotate(cat, t0.Add(time.Hour)) {
+		t.Error("Rotate() later the same day changed the board")
+	}
+	if b[0].Progress != 1 {
+		t.Errorf("progress = %d after rotating the same day, want 1", b[0].Progress)
+	}
+
+	// the next day only the daily quests are dealt again
+	weeklyBefore := make(map[string]bool)
+	for _, e := range b {
+		if e.Period == spec.PeriodWeekly {
+			weeklyBefore[e.ID] = true
+		}
+	}
+	next := t0.AddDate(0, 0, 1)
+	if !b.Rotate(cat, next) {
+		t.Fatal("Rotate() the next day reported no change")
+	}
+	if all, cur := dealt(b, spec.PeriodDaily, next); all != daily || cur != daily {
+		t.Errorf("daily quests the next day = %d (%d current), want %d", all, cur, daily)
+	}
+	for _, e := range b {
+		if e.Period == spec.PeriodWeekly && !weeklyBefore[e.ID] {
+			t.Errorf("weekly quest %s dealt midweek", e.ID)
+		}
+	}
+
+	// claimed or not, last week's quests go
+	for i := range b {
+		b[i].Claimed = true
+	}
+	nextWeek := t0.AddDate(0, 0, 7)
+	b.Rotate(cat, nextWeek)
+	for _, e := range b {
+		if e.Claimed || e.Key != Key(e.Period, nextWeek) {
+			t.Errorf("quest %s (%s, claimed %v) kept into the next week", e.ID, e.Key, e.Claimed)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	cat := loadCatalog(t)
+	win := model.MatchSummary{
+		Outcome:   spec.OutcomeWin,
+		Deploys:   map[string]int{"Archer": 3, "Mage": 2},
+		Destroyed: []string{"Guard Tower", "King Tower"},
+		Damage:    60,
+	}
+	tests := []struct {
+		name  string
+		entry Entry
+		m     model.MatchSummary
+		want  int
+	}{
+		{"deploys of one troop", Entry{ID: "deploy_archers"}, win, 3},
+		{"deploys of any troop", Entry{ID: "deploy_troops"}, win, 5},
+		{"towers of one kind", Entry{ID: "destroy_kings"}, win, 1},
+		{"towers of any kind", Entry{ID: "destroy_towers"}, win, 2},
+		{"win", Entry{ID: "win_3"}, win, 1},
+		{"loss", Entry{ID: "win_3"}, model.MatchSummary{Outcome: spec.OutcomeLoss}, 0},
+		{"play", Entry{ID: "play_5"}, model.MatchSummary{Outcome: spec.OutcomeLoss}, 1},
+		{"damage", Entry{ID: "damage_150", Progress: 10}, win, 70},
+		{"stops at the count", Entry{ID: "damage_150", Progress: 140}, win, 150},
+		{"claimed quests stay put", Entry{ID: "deploy_troops", Progress: 40, Claimed: true}, win, 40},
+		{"quests gone from the specs stay put", Entry{ID: "retired", Progress: 2}, win, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Board{tt.entry}
+			b.Record(cat, tt.m)
+			if b[0].Progress != tt.want {
+				t.Errorf("progress = %d, want %d", b[0].Progress, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaim(t *testing.T) {
+	cat := loadCatalog(t)
+	q, ok := cat.Quest("win_3")
+	if !ok {
+		t.Fatal("win_3 missing from the specs")
+	}
+	b := Board{{ID: "win_3", Progress: q.Count - 1}, {ID: "play_5"}}
+
+	if _, err := b.Claim(cat, "win_3"); !errors.Is(err, ErrNotComplete) {
+		t.Fatalf("Claim() before done error = %v, want %v", err, ErrNotComplete)
+	}
+	b[0].Progress = q.Count
+	reward, err := b.Claim(cat, "win_3")
+	if err != nil {
+		t.Fatalf("Claim() once done: %v", err)
+	}
+	if !b[0].Claimed {
+		t.Error("quest not marked claimed")
+	}
+	if reward[wallet.Gold] != q.Reward[wallet.Gold] || len(reward) != len(q.Reward) {
+		t.Errorf("reward = %v, want %v", reward, q.Reward)
+	}
+	reward[wallet.Gold] = 0 // mustn't reach the catalog
+	if again, _ := cat.Quest("win_3"); again.Reward[wallet.Gold] != q.Reward[wallet.Gold] {
+		t.Error("changing the reward changed the catalog")
+	}
+
+	if _, err := b.Claim(cat, "win_3"); !errors.Is(err, ErrAlreadyClaimed) {
+		t.Errorf("Claim() twice error = %v, want %v", err, ErrAlreadyClaimed)
+	}
+	if _, err := b.Claim(cat, "deploy_mages"); !errors.Is(err, ErrQuestNotFound) {
+		t.Errorf("Claim() of a quest not on the board error = %v, want %v", err, ErrQuestNotFound)
+	}
+}
@@ -0,0 +1,318 @@
+package season
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/game"
+	"clashroyale/internal/leaderboard"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+)
+
+// Dir is where closed seasons are archived, one JSON file each.
+var Dir = filepath.Join("data", "seasons")
+
+// Archive statuses.
+const (
+	StatusPaying = "paying" // standings fixed, accounts still being reset and paid
+	StatusClosed = "closed"
+)
+
+var ErrSeasonNotFound = errors.New("season not found")
+
+var (
+	// errClosed means an account has had the season's end applied already.
+	errClosed = errors.New("season already closed on account")
+	// errStale means an account's trophies or pass XP moved while it was
+	// being reset.
+	errStale = errors.New("balances changed during reset")
+)
+
+// Standing is a player's final place in a season.
+type Standing struct {
+	Rank         int             `json:"rank"`
+	Username     string          `json:"username"`
+	Trophies     int             `json:"trophies"` // before the reset
+	PeakTrophies int             `json:"peakTrophies"`
+	PeakRank     int             `json:"peakRank"`
+	Matches      int             `json:"matches"`
+	Reward       wallet.Balances `json:"reward,omitempty"`
+}
+
+// Archive is a season's final standings, written when it ends.
+type Archive struct {
+	Season    spec.Season      `json:"season"`
+	Status    string           `json:"status"`
+	Reset     spec.SeasonReset `json:"reset"`
+	ClosedAt  time.Time        `json:"closedAt,omitzero"`
+	Standings []Standing       `json:"standings"`
+}
+
+// Manager closes seasons as they end and keeps their archives. Register
+// HandleResult with game.OnFinish so matches count towards the season.
+type Manager struct {
+	dir      string
+	archives map[string]*Archive
+	mu       sync.Mutex
+}
+
+// NewManager loads the archives saved in dir. A season the server
+// stopped part way through closing is finished on the next RollOver.
+func NewManager(dir string) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	m := &Manager{dir: dir, archives: make(map[string]*Archive)}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var a Archive
+		if err := json.Unmarshal(b, &a); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		m.archives[a.Season.ID] = &a
+	}
+	return m, nil
+}
+
+// Run closes seasons as they end, checking every interval until stop is
+// closed.
+func (m *Manager) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			cat, err := spec.Current()
+			if err != nil {
+				continue
+			}
+			if err := m.RollOver(cat, now); err != nil {
+				log.Printf("season rollover: %v", err)
+			}
+		}
+	}
+}
+
+// RollOver closes every season in cat's calendar that has ended by now,
+// oldest first. It is safe to run again after a failure or restart: the
+// standings are fixed before anyone is paid, and each account records
+// the last season closed on it, so nobody is reset or paid twice.
+func (m *Manager) RollOver(cat *spec.Catalog, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rollOver(cat, now)
+}
+
+// rollOver is RollOver. Caller must hold m.mu.
+func (m *Manager) rollOver(cat *spec.Catalog, now time.Time) error {
+	for _, s := range cat.Seasons() {
+		if s.End.After(now) {
+			break
+		}
+		if a := m.archives[s.ID]; a != nil && a.Status == StatusClosed {
+			continue
+		}
+		if err := m.close(cat, s, now); err != nil {
+			return fmt.Errorf("closing season %s: %w", s.ID, err)
+		}
+		log.Printf("season %s closed", s.ID)
+	}
+	return nil
+}
+
+// close archives s's standings, then resets and pays every account.
+func (m *Manager) close(cat *spec.Catalog, s spec.Season, now time.Time) error {
+	users, err := auth.ListUsers()
+	if err != nil {
+		return err
+	}
+	a := m.archives[s.ID]
+	if a == nil {
+		a = &Archive{
+			Season:    s,
+			Status:    StatusPaying,
+			Reset:     cat.SeasonReset(),
+			Standings: standings(cat, s.ID, users),
+		}
+		if err := m.save(a); err != nil {
+			return err
+		}
+		m.archives[s.ID] = a
+	}
+
+	rewards := make(map[string]wallet.Balances, len(a.Standings))
+	for _, st := range a.Standings {
+		rewards[st.Username] = st.Reward
+	}
+	// everyone is reset, not just the players who took part
+	for _, u := range users {
+		if err := closeAccount(cat, a.Reset, s.ID, u.Username, rewards[u.Username]); err != nil {
+			return fmt.Errorf("%s: %w", u.Username, err)
+		}
+	}
+	a.Status = StatusClosed
+	a.ClosedAt = now
+	return m.save(a)
+}
+
+// standings ranks the players who finished a match in season id by their
+// trophies, and works out their rewards from their best rank.
+func standings(cat *spec.Catalog, id string, users []*auth.User) []Standing {
+	out := []Standing{}
+	for _, u := range users {
+		if u.Season.ID != id || u.Season.Matches == 0 || u.Season.Closed == id {
+			continue
+		}
+		out = append(out, Standing{
+			Username:     u.Username,
+			Trophies:     u.Wallet[wallet.Trophies],
+			PeakTrophies: u.Season.PeakTrophies,
+			PeakRank:     u.Season.PeakRank,
+			Matches:      u.Season.Matches,
+			Reward:       cat.SeasonReward(u.Season.PeakRank),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Trophies != out[j].Trophies {
+			return out[i].Trophies > out[j].Trophies
+		}
+		return out[i].Username < out[j].Username
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+// closeAccount applies the reset and pays reward to username, unless
+// season id has already been closed on the account. Like a lost match,
+// the reset can't take a player below their arena floor. Pass XP starts
+// again from zero, along with the battle pass itself on its next use.
+func closeAccount(cat *spec.Catalog, reset spec.SeasonReset, id, username string, reward wallet.Balances) error {
+	for {
+		u, err := auth.LoadUser(username)
+		if err != nil {
+			return err
+		}
+		if u.Season.Closed == id {
+			return nil
+		}
+		before := u.Wallet[wallet.Trophies]
+		after := max(reset.Apply(before), cat.ArenaFloor(before))
+		passXP := u.Wallet[wallet.PassXP]
+		changes := wallet.Balances{wallet.Trophies: after - before, wallet.PassXP: -passXP}
+		for cur, amt := range reward {
+			changes[cur] += amt
+		}
+		_, _, err = auth.Transact(username, changes, wallet.ReasonSeason, id, func(u *auth.User) error {
+			if u.Season.Closed == id {
+				return errClosed
+			}
+			if u.Wallet[wallet.Trophies] != after || u.Wallet[wallet.PassXP] != 0 {
+				return errStale
+			}
+			u.Season.Closed = id
+			return nil
+		})
+		switch {
+		case errors.Is(err, errClosed):
+			return nil
+		case errors.Is(err, errStale):
+			continue
+		}
+		return err
+	}
+}
+
+// HandleResult counts a finished game towards its players' season: their
+// matches, and their best trophies and leaderboard place.
+func (m *Manager) HandleResult(r game.Result) {
+	cat, err := spec.Current()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// a season that has just ended is closed first, so its standings
+	// can't pick up games played after it
+	if err := m.rollOver(cat, now); err != nil {
+		log.Printf("season rollover: %v", err)
+		return
+	}
+	s, ok := cat.SeasonAt(now)
+	if !ok {
+		return
+	}
+	for _, p := range r.Players {
+		_, err := auth.Update(p, func(u *auth.User) error {
+			u.Season.Record(s.ID, u.Wallet[wallet.Trophies], leaderboard.Rank(p))
+			return nil
+		})
+		if err != nil {
+			log.Printf("season %s: recording %s for %s: %v", s.ID, r.GameID, p, err)
+		}
+	}
+}
+
+// Archive returns a closed season's final standings.
+func (m *Manager) Archive(id string) (Archive, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.archives[id]
+	if !ok || a.Status != StatusClosed {
+		return Archive{}, fmt.Errorf("%w: %s", ErrSeasonNotFound, id)
+	}
+	out := *a
+	out.Standings = append([]Standing(nil), a.Standings...)
+	return out, nil
+}
+
+// Closed lists the closed seasons, most recent first, without their
+// standings.
+func (m *Manager) Closed() []Archive {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []Archive{}
+	for _, a := range m.archives {
+		if a.Status == StatusClosed {
+			out = append(out, Archive{Season: a.Season, Status: a.Status, Reset: a.Reset, ClosedAt: a.ClosedAt})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Season.End.After(out[j].Season.End) })
+	return out
+}
+
+// save writes the archive to disk through a temporary file, so a crash
+// can't leave half of one behind. Caller must hold m.mu.
+func (m *Manager) save(a *Archive) error {
+	path := filepath.Join(m.dir, a.Season.ID+".json")
+	b, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,171 @@
+package season
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"clashroyale/internal/auth"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/stats"
+	"clashroyale/internal/wallet"
+)
+
+// just after the first season in the specs ends
+var ended = time.Date(2026, 11, 1, 0, 0, 1, 0, time.UTC)
+
+// setup loads the repo's specs and moves into an empty data directory
+// holding ace, who finished the first season top of the leaderboard, and
+// bob, who didn't play in it.
+func setup(t *testing.T) *spec.Catalog {
+	t.Helper()
+	specs, err := filepath.Abs("../../specs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cat, err := spec.Load(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(filepath.Join("data", "players"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	users := []*auth.User{
+		{
+			Username: "ace",
+			Wallet:   wallet.Balances{wallet.Trophies: 3000, wallet.PassXP: 500},
+			Season:   stats.Season{ID: "2026-10", Matches: 5, PeakTrophies: 3100, PeakRank: 1},
+		},
+		{
+			Username: "bob",
+			Wallet:   wallet.Balances{wallet.Trophies: 1200, wallet.PassXP: 40},
+		},
+	}
+	for _, u := range users {
+		if err := auth.SaveUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cat
+}
+
+func load(t *testing.T, username string) *auth.User {
+	t.Helper()
+	u, err := auth.LoadUser(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// checkClosed checks the first season's end was applied to ace and bob
+// exactly once.
+func checkClosed(t *testing.T, cat *spec.Catalog) {
+	t.Helper()
+	reward := cat.SeasonReward(1)
+	tests := []struct {
+		username string
+		trophies int
+		gold     int
+	}{
+		{"ace", 2000, reward[wallet.Gold]},
+		{"bob", 1100, 0},
+	}
+	for _, tt := range tests {
+		u := load(t, tt.username)
+		if got := u.Wallet[wallet.Trophies]; got != tt.trophies {
+			t.Errorf("%s trophies = %d, want %d", tt.username, got, tt.trophies)
+		}
+		if got := u.Wallet[wallet.Gold]; got != tt.gold {
+			t.Errorf("%s gold = %d, want %d", tt.username, got, tt.gold)
+		}
+		if got := u.Wallet[wallet.PassXP]; got != 0 {
+			t.Errorf("%s pass XP = %d, want 0", tt.username, got)
+		}
+		if u.Season.Closed != "2026-10" {
+			t.Errorf("%s last closed season = %q, want 2026-10", tt.username, u.Season.Closed)
+		}
+	}
+}
+
+func TestRollOver(t *testing.T) {
+	cat := setup(t)
+	m, err := NewManager(Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RollOver(cat, ended.Add(-2*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.Closed()); got != 0 {
+		t.Fatalf("%d seasons closed before the first ended", got)
+	}
+
+	if err := m.RollOver(cat, ended); err != nil {
+		t.Fatal(err)
+	}
+	checkClosed(t, cat)
+	a, err := m.Archive("2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Standings) != 1 || a.Standings[0].Username != "ace" || a.Standings[0].Rank != 1 || a.Standings[0].Trophies != 3000 {
+		t.Errorf("standings = %+v, want ace first on 3000 trophies alone", a.Standings)
+	}
+	if _, err := m.Archive("2026-11"); err == nil {
+		t.Error("Archive() of a running season succeeded")
+	}
+
+	// again, and again after a restart
+	if err := m.RollOver(cat, ended.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	m, err = NewManager(Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RollOver(cat, ended.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	checkClosed(t, cat)
+	if got := len(m.Closed()); got != 1 {
+		t.Errorf("%d seasons closed, want 1", got)
+	}
+}
+
+// TestRollOverResumes picks a close up after the server stopped with
+// some accounts already reset and paid.
+func TestRollOverResumes(t *testing.T) {
+	cat := setup(t)
+	s := cat.Seasons()[0]
+	users, err := auth.ListUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Archive{Season: s, Status: StatusPaying, Reset: cat.SeasonReset(), Standings: standings(cat, s.ID, users)}
+	if err := m.save(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := closeAccount(cat, a.Reset, s.ID, "ace", a.Standings[0].Reward); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = NewManager(Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RollOver(cat, ended); err != nil {
+		t.Fatal(err)
+	}
+	checkClosed(t, cat)
+	if _, err := m.Archive(s.ID); err != nil {
+		t.Errorf("Archive() after resuming: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package spec
+
+import "fmt"
+
+// Lifetime stats an achievement can count.
+const (
+	StatMatches    = "matches"
+	StatWins       = "wins"
+	StatLosses     = "losses"
+	StatDraws      = "draws"
+	StatBestStreak = "bestStreak"
+	StatTowers     = "towersDestroyed"
+	StatDamage     = "damage"
+	StatDeploys    = "deploys"
+)
+
+var achievementStats = map[string]bool{
+	StatMatches: true, StatWins: true, StatLosses: true, StatDraws: true,
+	StatBestStreak: true, StatTowers: true, StatDamage: true, StatDeploys: true,
+}
+
+// Achievement is earned once a lifetime stat reaches Threshold.
+type Achievement struct {
+	ID        string `json:"id"`
+	Stat      string `json:"stat"`
+	Threshold int    `json:"threshold"`
+}
+
+// Achievements is achievements.json.
+type Achievements struct {
+	Achievements []Achievement `json:"achievements"`
+}
+
+// Achievements returns every achievement, in file order.
+func (c *Catalog) Achievements() []Achievement {
+	return append([]Achievement(nil), c.achievements.Achievements...)
+}
+
+func (as Achievements) validate() error {
+	seen := make(map[string]bool)
+	for i, a := range as.Achievements {
+		if a.ID == "" {
+			return fmt.Errorf("achievements.json[%d]: missing id", i)
+		}
+		if seen[a.ID] {
+			return fmt.Errorf("achievements.json: duplicate achievement %q", a.ID)
+		}
+		seen[a.ID] = true
+		if !achievementStats[a.Stat] {
+			return fmt.Errorf("achievements.json: achievement %q has unknown stat %q", a.ID, a.Stat)
+		}
+		if a.Threshold < 1 {
+			return fmt.Errorf("achievements.json: achievement %q threshold must be at least 1", a.ID)
+		}
+	}
+	return nil
+}
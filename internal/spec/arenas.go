@@ -0,0 +1,105 @@
+package spec
+
+import "fmt"
+
+// Arena is a trophy tier. Players enter it once they hold Trophies.
+type Arena struct {
+	ID         string   `json:"id"`
+	Trophies   int      `json:"trophies"`
+	Cards      []string `json:"cards,omitempty"` // troops unlocked on reaching it
+	Multiplier float64  `json:"multiplier"`      // scales match gold and EXP
+	Floor      bool     `json:"floor,omitempty"` // players can't lose trophies below Trophies once here
+}
+
+// Arenas is arenas.json, lowest arena first.
+type Arenas struct {
+	Arenas []Arena `json:"arenas"`
+}
+
+// Arenas returns every arena, lowest first.
+func (c *Catalog) Arenas() []Arena {
+	return append([]Arena(nil), c.arenas.Arenas...)
+}
+
+// ArenaFor returns the arena a player with trophies plays in, and its
+// place in the arena list.
+func (c *Catalog) ArenaFor(trophies int) (Arena, int) {
+	i := 0
+	for j, a := range c.arenas.Arenas {
+		if trophies >= a.Trophies {
+			i = j
+		}
+	}
+	return c.arenas.Arenas[i], i
+}
+
+// ArenaFloor returns the fewest trophies a player holding trophies can
+// drop to: the threshold of the highest floor arena they're in or past,
+// or 0.
+func (c *Catalog) ArenaFloor(trophies int) int {
+	floor := 0
+	for _, a := range c.arenas.Arenas {
+		if a.Floor && trophies >= a.Trophies {
+			floor = a.Trophies
+		}
+	}
+	return floor
+}
+
+// arenaOf returns the arena that unlocks the named troop.
+func (c *Catalog) arenaOf(troop string) (Arena, bool) {
+	for _, a := range c.arenas.Arenas {
+		for _, name := range a.Cards {
+			if name == troop {
+				return a, true
+			}
+		}
+	}
+	return Arena{}, false
+}
+
+// validate checks arenas.json: an arena open to everyone, thresholds
+// rising, and each known troop unlocked by one arena at most.
+func (as Arenas) validate(troops []string) error {
+	if len(as.Arenas) == 0 {
+		return fmt.Errorf("arenas.json: no arenas defined")
+	}
+	if as.Arenas[0].Trophies != 0 {
+		return fmt.Errorf("arenas.json: the first arena must need 0 trophies")
+	}
+	if len(as.Arenas[0].Cards) > 0 {
+		return fmt.Errorf("arenas.json: the first arena can't unlock cards, leave them out to make them starters")
+	}
+	known := make(map[string]bool, len(troops))
+	for _, name := range troops {
+		known[name] = true
+	}
+
+	seen := make(map[string]bool)
+	unlockedBy := make(map[string]string)
+	for i, a := range as.Arenas {
+		if a.ID == "" {
+			return fmt.Errorf("arenas.json[%d]: missing id", i)
+		}
+		if seen[a.ID] {
+			return fmt.Errorf("arenas.json: duplicate arena %q", a.ID)
+		}
+		seen[a.ID] = true
+		if i > 0 && a.Trophies <= as.Arenas[i-1].Trophies {
+			return fmt.Errorf("arenas.json: arena %q must need more trophies than %q", a.ID, as.Arenas[i-1].ID)
+		}
+		if a.Multiplier <= 0 {
+			return fmt.Errorf("arenas.json: arena %q multiplier must be positive", a.ID)
+		}
+		for _, name := range a.Cards {
+			if !known[name] {
+				return fmt.Errorf("arenas.json: arena %q unlocks unknown troop %q", a.ID, name)
+			}
+			if by, ok := unlockedBy[name]; ok {
+				return fmt.Errorf("arenas.json: troop %q is unlocked by both %q and %q", name, by, a.ID)
+			}
+			unlockedBy[name] = a.ID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package spec
+
+import "fmt"
+
+// Unlock is what a player needs before a troop joins their collection.
+// Every part that's set has to be met; Shards are spent when it unlocks.
+type Unlock struct {
+	Level    int `json:"level,omitempty"`    // player level
+	Trophies int `json:"trophies,omitempty"` // at least the arena that unlocks the troop, if any
+	Shards   int `json:"shards,omitempty"`   // card shards of this troop
+}
+
+// Cards is cards.json: the unlock requirement per troop. Troops neither
+// it nor an arena lists are starter cards every player owns from the
+// start.
+type Cards struct {
+	Unlocks map[string]Unlock `json:"unlocks"`
+}
+
+// Unlock returns what the named troop needs to be unlocked, or false if
+// it's a starter card. A troop an arena unlocks needs that arena's
+// trophies on top of anything cards.json asks for.
+func (c *Catalog) Unlock(name string) (Unlock, bool) {
+	u, ok := c.cards.Unlocks[name]
+	if a, found := c.arenaOf(name); found {
+		u.Trophies = max(u.Trophies, a.Trophies)
+		ok = true
+	}
+	return u, ok
+}
+
+// Starters returns the names of the starter cards, in catalog order.
+func (c *Catalog) Starters() []string {
+	var out []string
+	for _, t := range c.troops {
+		if _, ok := c.Unlock(t.Name); !ok {
+			out = append(out, t.Name)
+		}
+	}
+	return out
+}
+
+// validate checks cards.json only names known troops and asks for
+// something. Catalog.Validate checks there are starters left.
+func (cs Cards) validate(troops []string) error {
+	known := make(map[string]bool, len(troops))
+	for _, name := range troops {
+		known[name] = true
+	}
+	for name, u := range cs.Unlocks {
+		if !known[name] {
+			return fmt.Errorf("cards.json: unknown troop %q", name)
+		}
+		if u.Level < 0 || u.Trophies < 0 || u.Shards < 0 {
+			return fmt.Errorf("cards.json: troop %q has a negative requirement", name)
+		}
+		if u == (Unlock{}) {
+			return fmt.Errorf("cards.json: troop %q has no requirement, leave it out to make it a starter", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"clashroyale/internal/model"
+)
+
+// MaxMana is the mana cap a player can hold during a match.
+// No troop may cost more than this, or it could never be deployed.
+const MaxMana = 10
+
+// Dir is where the spec files live.
+var Dir = "specs"
+
+// Catalog is an immutable, validated snapshot of troops.json, towers.json,
+// rewards.json, upgrades.json, cards.json, chests.json, quests.json,
+// achievements.json, seasons.json, arenas.json and pass.json.
+// Callers only ever get copies, so a running match can hold on to the
+// catalog it started with while a reload swaps in a new one.
+type Catalog struct {
+	Version  int
+	LoadedAt time.Time
+	troops   []model.Troop
+	towers   []model.Tower
+	rewards  Rewards
+	upgrades Upgrades
+	cards    Cards
+	chests   Chests
+	quests   Quests
+
+	achievements Achievements
+	seasons      Seasons
+	arenas       Arenas
+	pass         Pass
+}
+
+var (
+	current atomic.Pointer[Catalog]
+	version atomic.Int64
+)
+
+// Load reads and validates the spec files in dir.
+func Load(dir string) (*Catalog, error) {
+	var troops []model.Troop
+	if err := readJSON(filepath.Join(dir, "troops.json"), &troops); err != nil {
+		return nil, err
+	}
+	var towers []model.Tower
+	if err := readJSON(filepath.Join(dir, "towers.json"), &towers); err != nil {
+		return nil, err
+	}
+
+	var rewards Rewards
+	if err := readJSON(filepath.Join(dir, "rewards.json"), &rewards); err != nil {
+		return nil, err
+	}
+
+	var upgrades Upgrades
+	if err := readJSON(filepath.Join(dir, "upgrades.json"), &upgrades); err != nil {
+		return nil, err
+	}
+
+	var cards Cards
+	if err := readJSON(filepath.Join(dir, "cards.json"), &cards); err != nil {
+		return nil, err
+	}
+
+	var chests Chests
+	if err := readJSON(filepath.Join(dir, "chests.json"), &chests); err != nil {
+		return nil, err
+	}
+
+	var quests Quests
+	if err := readJSON(filepath.Join(dir, "quests.json"), &quests); err != nil {
+		return nil, err
+	}
+
+	var achievements Achievements
+	if err := readJSON(filepath.Join(dir, "achievements.json"), &achievements); err != nil {
+		return nil, err
+	}
+
+	var seasons Seasons
+	if err := readJSON(filepath.Join(dir, "seasons.json"), &seasons); err != nil {
+		return nil, err
+	}
+
+	var arenas Arenas
+	if err := readJSON(filepath.Join(dir, "arenas.json"), &arenas); err != nil {
+		return nil, err
+	}
+
+	var pass Pass
+	if err := readJSON(filepath.Join(dir, "pass.json"), &pass); err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{
+		troops: troops, towers: towers, rewards: rewards, upgrades: upgrades, cards: cards,
+		chests: chests, quests: quests, achievements: achievements, seasons: seasons,
+		arenas: arenas, pass: pass, LoadedAt: time.Now(),
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks the schema rules every catalog must satisfy.
+func (c *Catalog) Validate() error {
+	if len(c.troops) == 0 {
+		return fmt.Errorf("troops.json: no troops defined")
+	}
+	if len(c.towers) == 0 {
+		return fmt.Errorf("towers.json: no towers defined")
+	}
+
+	seen := make(map[string]bool)
+	for i, t := range c.troops {
+		if t.Name == "" {
+			return fmt.Errorf("troops.json[%d]: missing name", i)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("troops.json: duplicate troop %q", t.Name)
+		}
+		seen[t.Name] = true
+		if t.HP < 0 || t.ATK < 0 || t.DEF < 0 || t.Exp < 0 || t.Level < 0 {
+			return fmt.Errorf("troops.json: troop %q has a negative stat", t.Name)
+		}
+		if t.Cost < 0 || t.Cost > MaxMana {
+			return fmt.Errorf("troops.json: troop %q mana cost %d outside 0..%d", t.Name, t.Cost, MaxMana)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i, t := range c.towers {
+		if t.Name == "" {
+			return fmt.Errorf("towers.json[%d]: missing name", i)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("towers.json: duplicate tower %q", t.Name)
+		}
+		seen[t.Name] = true
+		if t.HP < 0 || t.ATK < 0 || t.DEF < 0 || t.Exp < 0 || t.Level < 0 {
+			return fmt.Errorf("towers.json: tower %q has a negative stat", t.Name)
+		}
+		if t.Crit < 0 || t.Crit > 1 {
+			return fmt.Errorf("towers.json: tower %q crit %.2f outside 0..1", t.Name, t.Crit)
+		}
+	}
+
+	if err := c.rewards.validate(); err != nil {
+		return err
+	}
+
+	troopNames := make([]string, len(c.troops))
+	for i, t := range c.troops {
+		troopNames[i] = t.Name
+	}
+	towerNames := make([]string, len(c.towers))
+	for i, t := range c.towers {
+		towerNames[i] = t.Name
+	}
+	if err := c.upgrades.validate(troopNames, towerNames); err != nil {
+		return err
+	}
+	if err := c.cards.validate(troopNames); err != nil {
+		return err
+	}
+	if err := c.arenas.validate(troopNames); err != nil {
+		return err
+	}
+	if len(c.Starters()) == 0 {
+		return fmt.Errorf("cards.json, arenas.json: no starter cards left")
+	}
+	if err := c.chests.validate(c.upgrades.Rarities); err != nil {
+		return err
+	}
+	if err := c.quests.validate(troopNames, towerNames); err != nil {
+		return err
+	}
+	if err := c.achievements.validate(); err != nil {
+		return err
+	}
+	if err := c.seasons.validate(); err != nil {
+		return err
+	}
+	return c.pass.validate()
+}
+
+// Troops returns fresh copies of every troop spec.
+func (c *Catalog) Troops() []*model.Troop {
+	out := make([]*model.Troop, len(c.troops))
+	for i := range c.troops {
+		t := c.troops[i]
+		out[i] = &t
+	}
+	return out
+}
+
+// Towers returns fresh copies of every tower spec.
+func (c *Catalog) Towers() []*model.Tower {
+	out := make([]*model.Tower, len(c.towers))
+	for i := range c.towers {
+		t := c.towers[i]
+		out[i] = &t
+	}
+	return out
+}
+
+// Troop returns a copy of the named troop spec.
+func (c *Catalog) Troop(name string) (*model.Troop, bool) {
+	for i := range c.troops {
+		if c.troops[i].Name == name {
+			t := c.troops[i]
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// Tower returns a copy of the named tower spec.
+func (c *Catalog) Tower(name string) (*model.Tower, bool) {
+	for i := range c.towers {
+		if c.towers[i].Name == name {
+			t := c.towers[i]
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// catalogJSON is how a catalog is written out, e.g. inside a saved match.
+type catalogJSON struct {
+	Version  int           `json:"version"`
+	LoadedAt time.Time     `json:"loadedAt"`
+	Troops   []model.Troop `json:"troops"`
+	Towers   []model.Tower `json:"towers"`
+	Rewards  Rewards       `json:"rewards"`
+	Upgrades Upgrades      `json:"upgrades"`
+	Cards    Cards         `json:"cards"`
+	Chests   Chests        `json:"chests"`
+	Quests   Quests        `json:"quests"`
+
+	Achievements Achievements `json:"achievements"`
+	Seasons      Seasons      `json:"seasons"`
+	Arenas       Arenas       `json:"arenas"`
+	Pass         Pass         `json:"pass"`
+}
+
+// MarshalJSON writes the catalog with its troops and towers, so a match
+// saved mid-game can be restored with the specs it started with.
+func (c *Catalog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(catalogJSON{
+		c.Version, c.LoadedAt, c.troops, c.towers, c.rewards, c.upgrades,
+		c.cards, c.chests, c.quests, c.achievements, c.seasons, c.arenas,
+		c.pass,
+	})
+}
+
+// UnmarshalJSON reads a catalog written by MarshalJSON and validates it.
+func (c *Catalog) UnmarshalJSON(b []byte) error {
+	var cj catalogJSON
+	if err := json.Unmarshal(b, &cj); err != nil {
+		return err
+	}
+	*c = Catalog{
+		Version: cj.Version, LoadedAt: cj.LoadedAt,
+		troops: cj.Troops, towers: cj.Towers, rewards: cj.Rewards, upgrades: cj.Upgrades,
+		cards: cj.Cards, chests: cj.Chests, quests: cj.Quests, achievements: cj.Achievements,
+		seasons: cj.Seasons, arenas: cj.Arenas, pass: cj.Pass,
+	}
+	return c.Validate()
+}
+
+// Current returns the active catalog, loading it on first use.
+func Current() (*Catalog, error) {
+	if c := current.Load(); c != nil {
+		return c, nil
+	}
+	if err := Reload(); err != nil {
+		return nil, err
+	}
+	return current.Load(), nil
+}
+
+// Reload re-reads the spec files and, if they validate, atomically
+// swaps them in. On error the previous catalog stays active.
+func Reload() error {
+	c, err := Load(Dir)
+	if err != nil {
+		return err
+	}
+	c.Version = int(version.Add(1))
+	current.Store(c)
+	return nil
+}
+
+// Watch polls the spec files every interval and reloads the catalog when
+// any of them changes. It returns when stop is closed.
+func Watch(interval time.Duration, stop <-chan struct{}) {
+	last := modTime()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			mt := modTime()
+			if mt.Equal(last) {
+				continue
+			}
+			last = mt
+			if err := Reload(); err != nil {
+				log.Printf("spec reload rejected, keeping current catalog: %v", err)
+				continue
+			}
+			c, _ := Current()
+			log.Printf("spec catalog reloaded (version %d)", c.Version)
+		}
+	}
+}
+
+// modTime returns the newest modification time across the spec files.
+func modTime() time.Time {
+	var newest time.Time
+	for _, name := range []string{"troops.json", "towers.json", "rewards.json", "upgrades.json", "cards.json", "chests.json", "quests.json", "achievements.json", "seasons.json", "arenas.json", "pass.json"} {
+		if fi, err := os.Stat(filepath.Join(Dir, name)); err == nil && fi.ModTime().After(newest) {
+			newest = fi.ModTime()
+		}
+	}
+	return newest
+}
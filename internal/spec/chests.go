@@ -0,0 +1,133 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Range is an inclusive min..max amount rolled uniformly.
+type Range struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Roll picks an amount in the range.
+func (r Range) Roll() int {
+	return r.Min + rand.Intn(r.Max-r.Min+1)
+}
+
+func (r Range) validate() error {
+	if r.Min < 0 || r.Max < r.Min {
+		return fmt.Errorf("range %d..%d is invalid", r.Min, r.Max)
+	}
+	return nil
+}
+
+// ShardDrop is a stack of card shards for one troop of a rarity.
+type ShardDrop struct {
+	Rarity string `json:"rarity"`
+	Range
+}
+
+// Chest is one chest type: how long it takes to unlock and what's in it.
+type Chest struct {
+	UnlockSeconds int         `json:"unlockSeconds"`
+	Gold          Range       `json:"gold"`
+	Exp           Range       `json:"exp"`
+	Shards        []ShardDrop `json:"shards,omitempty"`
+}
+
+// UnlockTime is how long the chest takes to unlock.
+func (ch Chest) UnlockTime() time.Duration {
+	return time.Duration(ch.UnlockSeconds) * time.Second
+}
+
+// Chests is chests.json: how many chests a player can hold, which chest a
+// win drops (by weight), and each chest type.
+type Chests struct {
+	Slots  int              `json:"slots"`
+	Drops  map[string]int   `json:"drops"`
+	Chests map[string]Chest `json:"chests"`
+}
+
+// Chest returns the named chest type.
+func (c *Catalog) Chest(name string) (Chest, bool) {
+	ch, ok := c.chests.Chests[name]
+	return ch, ok
+}
+
+// ChestSlots is how many chests a player can hold at once.
+func (c *Catalog) ChestSlots() int {
+	return c.chests.Slots
+}
+
+// DropChest picks the chest type a win earns.
+func (c *Catalog) DropChest() string {
+	names := make([]string, 0, len(c.chests.Drops))
+	total := 0
+	for name, w := range c.chests.Drops {
+		names = append(names, name)
+		total += w
+	}
+	sort.Strings(names)
+	n := rand.Intn(total)
+	for _, name := range names {
+		n -= c.chests.Drops[name]
+		if n < 0 {
+			return name
+		}
+	}
+	return names[len(names)-1]
+}
+
+// TroopsOfRarity returns the names of the troops whose upgrade curve has
+// the given rarity, in catalog order.
+func (c *Catalog) TroopsOfRarity(rarity string) []string {
+	var out []string
+	for _, t := range c.troops {
+		if cv, ok := c.Curve(KindTroop, t.Name); ok && cv.Rarity == rarity {
+			out = append(out, t.Name)
+		}
+	}
+	return out
+}
+
+// validate checks chests.json against the upgrade rarities.
+func (cs Chests) validate(rarities map[string]Curve) error {
+	if cs.Slots < 1 {
+		return fmt.Errorf("chests.json: slots must be at least 1")
+	}
+	if len(cs.Drops) == 0 {
+		return fmt.Errorf("chests.json: no drops defined")
+	}
+	for name, w := range cs.Drops {
+		if _, ok := cs.Chests[name]; !ok {
+			return fmt.Errorf("chests.json: drop of unknown chest %q", name)
+		}
+		if w <= 0 {
+			return fmt.Errorf("chests.json: drop weight for %q must be positive", name)
+		}
+	}
+	for name, ch := range cs.Chests {
+		if ch.UnlockSeconds < 0 {
+			return fmt.Errorf("chests.json: chest %q has a negative unlock time", name)
+		}
+		if err := ch.Gold.validate(); err != nil {
+			return fmt.Errorf("chests.json: chest %q gold: %w", name, err)
+		}
+		if err := ch.Exp.validate(); err != nil {
+			return fmt.Errorf("chests.json: chest %q exp: %w", name, err)
+		}
+		for _, sd := range ch.Shards {
+			if _, ok := rarities[sd.Rarity]; !ok {
+				return fmt.Errorf("chests.json: chest %q drops shards of unknown rarity %q", name, sd.Rarity)
+			}
+			if err := sd.validate(); err != nil {
+				return fmt.Errorf("chests.json: chest %q %s shards: %w", name, sd.Rarity, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package spec
+
+import (
+	"fmt"
+
+	"clashroyale/internal/wallet"
+)
+
+// Battle pass tracks. Everyone can claim the free track; the premium one
+// needs the pass granted for the season.
+const (
+	TrackFree    = "free"
+	TrackPremium = "premium"
+)
+
+// Tracks lists the battle pass tracks in display order.
+var Tracks = []string{TrackFree, TrackPremium}
+
+// PassTier is one step of the battle pass and what each track pays for
+// reaching it. Either track may pay nothing.
+type PassTier struct {
+	Free    wallet.Balances `json:"free,omitempty"`
+	Premium wallet.Balances `json:"premium,omitempty"`
+}
+
+// Pass is pass.json: the battle pass run each season. Tier n (from 1) is
+// reached with n times TierXP pass XP.
+type Pass struct {
+	TierXP int        `json:"tierXP"`
+	Tiers  []PassTier `json:"tiers"`
+}
+
+// Pass returns the battle pass tiers.
+func (c *Catalog) Pass() []PassTier {
+	return append([]PassTier(nil), c.pass.Tiers...)
+}
+
+// PassTierXP returns the pass XP each tier takes.
+func (c *Catalog) PassTierXP() int {
+	return c.pass.TierXP
+}
+
+// PassTierAt returns how many tiers xp pass XP reaches, at most all of
+// them.
+func (c *Catalog) PassTierAt(xp int) int {
+	return min(max(xp, 0)/c.pass.TierXP, len(c.pass.Tiers))
+}
+
+// PassReward returns a copy of what tier (from 1) pays on track, and
+// false if there's no such tier or track.
+func (c *Catalog) PassReward(track string, tier int) (wallet.Balances, bool) {
+	if tier < 1 || tier > len(c.pass.Tiers) {
+		return nil, false
+	}
+	var from wallet.Balances
+	switch track {
+	case TrackFree:
+		from = c.pass.Tiers[tier-1].Free
+	case TrackPremium:
+		from = c.pass.Tiers[tier-1].Premium
+	default:
+		return nil, false
+	}
+	reward := make(wallet.Balances, len(from))
+	for cur, amt := range from {
+		reward[cur] = amt
+	}
+	return reward, true
+}
+
+func (p Pass) validate() error {
+	if p.TierXP < 1 {
+		return fmt.Errorf("pass.json: tierXP must be at least 1")
+	}
+	if len(p.Tiers) == 0 {
+		return fmt.Errorf("pass.json: no tiers defined")
+	}
+	for i, t := range p.Tiers {
+		for track, reward := range map[string]wallet.Balances{TrackFree: t.Free, TrackPremium: t.Premium} {
+			if err := reward.Validate(); err != nil {
+				return fmt.Errorf("pass.json: tiers[%d] %s: %w", i, track, err)
+			}
+			for cur, amt := range reward {
+				if amt < 0 {
+					return fmt.Errorf("pass.json: tiers[%d] has a negative %s %s reward", i, track, cur)
+				}
+				if cur == wallet.Trophies || cur == wallet.PassXP {
+					return fmt.Errorf("pass.json: tiers[%d] %s can't pay %s", i, track, cur)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package spec
+
+import (
+	"fmt"
+
+	"clashroyale/internal/wallet"
+)
+
+// Quest periods: how often a player's quests of that kind rotate.
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// What a quest counts.
+const (
+	QuestDeploy  = "deploy"  // troops deployed, or deploys of Target
+	QuestDestroy = "destroy" // towers destroyed, or Target towers
+	QuestWin     = "win"     // matches won
+	QuestPlay    = "play"    // matches finished
+	QuestDamage  = "damage"  // damage dealt to towers
+)
+
+// Quest is one quest players can be given.
+type Quest struct {
+	ID     string          `json:"id"`
+	Period string          `json:"period"`
+	Kind   string          `json:"kind"`
+	Target string          `json:"target,omitempty"` // troop or tower name, deploy and destroy only
+	Count  int             `json:"count"`
+	Reward wallet.Balances `json:"reward"`
+}
+
+// Quests is quests.json: how many quests each player gets per period,
+// and the pool they're drawn from.
+type Quests struct {
+	Daily  int     `json:"daily"`
+	Weekly int     `json:"weekly"`
+	Quests []Quest `json:"quests"`
+}
+
+// Quest returns the quest with the given ID.
+func (c *Catalog) Quest(id string) (Quest, bool) {
+	for _, q := range c.quests.Quests {
+		if q.ID == id {
+			return q, true
+		}
+	}
+	return Quest{}, false
+}
+
+// QuestPool returns the quests of a period and how many of them each
+// player gets at a time.
+func (c *Catalog) QuestPool(period string) ([]Quest, int) {
+	var pool []Quest
+	for _, q := range c.quests.Quests {
+		if q.Period == period {
+			pool = append(pool, q)
+		}
+	}
+	if period == PeriodWeekly {
+		return pool, c.quests.Weekly
+	}
+	return pool, c.quests.Daily
+}
+
+// validate checks quests.json against the troops and towers.
+func (qs Quests) validate(troops, towers []string) error {
+	known := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	seen := make(map[string]bool)
+	perPeriod := make(map[string]int)
+	for i, q := range qs.Quests {
+		if q.ID == "" {
+			return fmt.Errorf("quests.json[%d]: missing id", i)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("quests.json: duplicate quest %q", q.ID)
+		}
+		seen[q.ID] = true
+		if q.Period != PeriodDaily && q.Period != PeriodWeekly {
+			return fmt.Errorf("quests.json: quest %q has unknown period %q", q.ID, q.Period)
+		}
+		perPeriod[q.Period]++
+		switch q.Kind {
+		case QuestDeploy:
+			if q.Target != "" && !known(troops, q.Target) {
+				return fmt.Errorf("quests.json: quest %q targets unknown troop %q", q.ID, q.Target)
+			}
+		case QuestDestroy:
+			if q.Target != "" && !known(towers, q.Target) {
+				return fmt.Errorf("quests.json: quest %q targets unknown tower %q", q.ID, q.Target)
+			}
+		case QuestWin, QuestPlay, QuestDamage:
+			if q.Target != "" {
+				return fmt.Errorf("quests.json: %s quest %q can't have a target", q.Kind, q.ID)
+			}
+		default:
+			return fmt.Errorf("quests.json: quest %q has unknown kind %q", q.ID, q.Kind)
+		}
+		if q.Count < 1 {
+			return fmt.Errorf("quests.json: quest %q count must be at least 1", q.ID)
+		}
+		if err := q.Reward.Validate(); err != nil {
+			return fmt.Errorf("quests.json: quest %q: %w", q.ID, err)
+		}
+		for cur, amt := range q.Reward {
+			if amt < 0 {
+				return fmt.Errorf("quests.json: quest %q has a negative %s reward", q.ID, cur)
+			}
+		}
+	}
+	if qs.Daily < 0 || qs.Daily > perPeriod[PeriodDaily] {
+		return fmt.Errorf("quests.json: daily is %d but there are %d daily quests", qs.Daily, perPeriod[PeriodDaily])
+	}
+	if qs.Weekly < 0 || qs.Weekly > perPeriod[PeriodWeekly] {
+		return fmt.Errorf("quests.json: weekly is %d but there are %d weekly quests", qs.Weekly, perPeriod[PeriodWeekly])
+	}
+	return nil
+}
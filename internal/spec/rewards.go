@@ -0,0 +1,109 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+
+	"clashroyale/internal/wallet"
+)
+
+// Match outcomes, as keys of the reward table.
+const (
+	OutcomeWin  = "win"
+	OutcomeDraw = "draw"
+	OutcomeLoss = "loss"
+)
+
+// Rewards is rewards.json: what each player on a side is paid for a
+// match outcome, plus a bonus for how they played.
+type Rewards struct {
+	Outcomes    map[string]wallet.Balances `json:"outcomes"`
+	Performance Performance                `json:"performance"`
+}
+
+// Performance turns a player's match tally into bonus EXP. Each part is
+// its rate times the tally figure, rounded down and capped; the sum is
+// capped again by MaxExp.
+type Performance struct {
+	TowerExpRate  float64 `json:"towerExpRate"`  // per point of Exp on towers the player destroyed
+	TroopExpRate  float64 `json:"troopExpRate"`  // per point of Exp on troops the player deployed
+	DamageExpRate float64 `json:"damageExpRate"` // per point of damage dealt to towers
+	MaxTowerExp   int     `json:"maxTowerExp"`
+	MaxTroopExp   int     `json:"maxTroopExp"`
+	MaxDamageExp  int     `json:"maxDamageExp"`
+	MaxExp        int     `json:"maxExp"`
+}
+
+// Bonus is the performance EXP for one player, part by part.
+type Bonus struct {
+	Towers int  `json:"towers"`
+	Troops int  `json:"troops"`
+	Damage int  `json:"damage"`
+	Total  int  `json:"total"`
+	Capped bool `json:"capped"` // some part, or the total, hit its cap
+}
+
+// Bonus works out the EXP for a player who destroyed towers worth
+// towerExp, deployed troops worth troopExp and dealt damage to towers.
+func (p Performance) Bonus(towerExp, troopExp, damage int) Bonus {
+	var b Bonus
+	part := func(rate float64, n, limit int) int {
+		v := int(math.Floor(rate * float64(n)))
+		if v > limit {
+			b.Capped = true
+			return limit
+		}
+		return v
+	}
+	b.Towers = part(p.TowerExpRate, towerExp, p.MaxTowerExp)
+	b.Troops = part(p.TroopExpRate, troopExp, p.MaxTroopExp)
+	b.Damage = part(p.DamageExpRate, damage, p.MaxDamageExp)
+	b.Total = b.Towers + b.Troops + b.Damage
+	if b.Total > p.MaxExp {
+		b.Total = p.MaxExp
+		b.Capped = true
+	}
+	return b
+}
+
+// Reward returns a copy of what one player earns for outcome.
+func (c *Catalog) Reward(outcome string) wallet.Balances {
+	out := make(wallet.Balances, len(c.rewards.Outcomes[outcome]))
+	for k, v := range c.rewards.Outcomes[outcome] {
+		out[k] = v
+	}
+	return out
+}
+
+// Performance returns the performance bonus formula.
+func (c *Catalog) Performance() Performance {
+	return c.rewards.Performance
+}
+
+func (r Rewards) validate() error {
+	for _, o := range []string{OutcomeWin, OutcomeDraw, OutcomeLoss} {
+		if _, ok := r.Outcomes[o]; !ok {
+			return fmt.Errorf("rewards.json: no rewards for %q", o)
+		}
+	}
+	for o, b := range r.Outcomes {
+		if o != OutcomeWin && o != OutcomeDraw && o != OutcomeLoss {
+			return fmt.Errorf("rewards.json: unknown outcome %q", o)
+		}
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("rewards.json: %s: %w", o, err)
+		}
+		if b[wallet.Gold] < 0 || b[wallet.Exp] < 0 || b[wallet.PassXP] < 0 {
+			return fmt.Errorf("rewards.json: %s: gold, EXP and pass XP rewards can't be negative", o)
+		}
+	}
+
+	p := r.Performance
+	if p.TowerExpRate < 0 || p.TroopExpRate < 0 || p.DamageExpRate < 0 {
+		return fmt.Errorf("rewards.json: performance rates can't be negative")
+	}
+	if p.MaxTowerExp < 0 || p.MaxTroopExp < 0 || p.MaxDamageExp < 0 || p.MaxExp < 0 {
+		return fmt.Errorf("rewards.json: performance caps can't be negative")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package spec
+
+import (
+	"fmt"
+	"time"
+
+	"clashroyale/internal/wallet"
+)
+
+// Season is one entry in the season calendar.
+type Season struct {
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Contains reports whether now falls in the season.
+func (s Season) Contains(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.End)
+}
+
+// SeasonReset is the trophy soft-reset applied when a season ends:
+// players above Floor keep Keep percent of what they hold above it.
+type SeasonReset struct {
+	Floor int `json:"floor"`
+	Keep  int `json:"keep"`
+}
+
+// Apply returns trophies after the reset.
+func (r SeasonReset) Apply(trophies int) int {
+	if trophies <= r.Floor {
+		return trophies
+	}
+	return r.Floor + (trophies-r.Floor)*r.Keep/100
+}
+
+// SeasonReward is paid to players whose best leaderboard rank during
+// the season was Rank or better.
+type SeasonReward struct {
+	Rank   int             `json:"rank"`
+	Reward wallet.Balances `json:"reward"`
+}
+
+// Seasons is seasons.json: the calendar, the reset and the end-of-season
+// rewards, best ranks first.
+type Seasons struct {
+	Reset   SeasonReset    `json:"reset"`
+	Rewards []SeasonReward `json:"rewards"`
+	Seasons []Season       `json:"seasons"`
+}
+
+// Seasons returns the calendar in order.
+func (c *Catalog) Seasons() []Season {
+	return append([]Season(nil), c.seasons.Seasons...)
+}
+
+// SeasonAt returns the season containing now, if any.
+func (c *Catalog) SeasonAt(now time.Time) (Season, bool) {
+	for _, s := range c.seasons.Seasons {
+		if s.Contains(now) {
+			return s, true
+		}
+	}
+	return Season{}, false
+}
+
+// SeasonReset returns the trophy soft-reset.
+func (c *Catalog) SeasonReset() SeasonReset {
+	return c.seasons.Reset
+}
+
+// SeasonReward returns a copy of the reward for a best rank of peakRank,
+// or nil if it earns none. A peakRank of 0 means unranked.
+func (c *Catalog) SeasonReward(peakRank int) wallet.Balances {
+	if peakRank < 1 {
+		return nil
+	}
+	for _, r := range c.seasons.Rewards {
+		if peakRank <= r.Rank {
+			reward := make(wallet.Balances, len(r.Reward))
+			for cur, amt := range r.Reward {
+				reward[cur] = amt
+			}
+			return reward
+		}
+	}
+	return nil
+}
+
+func (ss Seasons) validate() error {
+	if ss.Reset.Floor < 0 {
+		return fmt.Errorf("seasons.json: reset floor can't be negative")
+	}
+	if ss.Reset.Keep < 0 || ss.Reset.Keep > 100 {
+		return fmt.Errorf("seasons.json: reset keep %d outside 0..100", ss.Reset.Keep)
+	}
+
+	for i, r := range ss.Rewards {
+		if r.Rank < 1 {
+			return fmt.Errorf("seasons.json: rewards[%d] rank must be at least 1", i)
+		}
+		if i > 0 && r.Rank <= ss.Rewards[i-1].Rank {
+			return fmt.Errorf("seasons.json: rewards must be in increasing rank order")
+		}
+		if err := r.Reward.Validate(); err != nil {
+			return fmt.Errorf("seasons.json: rewards[%d]: %w", i, err)
+		}
+		for cur, amt := range r.Reward {
+			if amt < 0 {
+				return fmt.Errorf("seasons.json: rewards[%d] has a negative %s reward", i, cur)
+			}
+			if cur == wallet.Trophies || cur == wallet.PassXP {
+				return fmt.Errorf("seasons.json: rewards[%d] can't pay %s", i, cur)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, s := range ss.Seasons {
+		if s.ID == "" {
+			return fmt.Errorf("seasons.json: seasons[%d]: missing id", i)
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("seasons.json: duplicate season %q", s.ID)
+		}
+		seen[s.ID] = true
+		if !s.End.After(s.Start) {
+			return fmt.Errorf("seasons.json: season %q must end after it starts", s.ID)
+		}
+		if i > 0 && s.Start.Before(ss.Seasons[i-1].End) {
+			return fmt.Errorf("seasons.json: season %q starts before %q ends", s.ID, ss.Seasons[i-1].ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+)
+
+// Unit kinds, as used in upgrades.json.
+const (
+	KindTroop = "troop"
+	KindTower = "tower"
+)
+
+// Stats a curve can grow.
+const (
+	StatHP  = "hp"
+	StatATK = "atk"
+	StatDEF = "def"
+)
+
+// Curve is how a unit levels up. Levels start at 1 and stop at MaxLevel;
+// Costs[i] is the gold to go from level i+1 to i+2. Growth is each stat's
+// multiplier per level: a stat at level n is its base times Growth^n,
+// rounded up. A stat with no growth stays at its base value.
+type Curve struct {
+	Rarity   string             `json:"rarity,omitempty"`
+	MaxLevel int                `json:"maxLevel,omitempty"`
+	Costs    []int              `json:"costs,omitempty"`
+	Growth   map[string]float64 `json:"growth,omitempty"`
+}
+
+// Upgrades is upgrades.json. Rarities are shared curves; a unit's own
+// entry can pick one and override any part of it.
+type Upgrades struct {
+	Rarities map[string]Curve `json:"rarities"`
+	Troops   map[string]Curve `json:"troops"`
+	Towers   map[string]Curve `json:"towers"`
+}
+
+// Level clamps level to the curve's range.
+func (cv Curve) Level(level int) int {
+	return max(1, min(level, cv.MaxLevel))
+}
+
+// Stat returns stat at level, grown from base.
+func (cv Curve) Stat(stat string, base, level int) int {
+	g, ok := cv.Growth[stat]
+	if !ok {
+		return base
+	}
+	return int(math.Ceil(float64(base) * math.Pow(g, float64(cv.Level(level)))))
+}
+
+// Cost returns the gold to go from level to the next one, or false if
+// level is already the top.
+func (cv Curve) Cost(level int) (int, bool) {
+	level = cv.Level(level)
+	if level >= cv.MaxLevel {
+		return 0, false
+	}
+	return cv.Costs[level-1], true
+}
+
+// Curve returns the upgrade curve for the named troop or tower, with its
+// rarity's values filled in.
+func (c *Catalog) Curve(kind, name string) (Curve, bool) {
+	units := c.upgrades.Troops
+	if kind == KindTower {
+		units = c.upgrades.Towers
+	}
+	own, ok := units[name]
+	if !ok {
+		return Curve{}, false
+	}
+	return c.upgrades.resolve(own), true
+}
+
+// resolve fills in the parts of own it leaves to its rarity.
+func (u Upgrades) resolve(own Curve) Curve {
+	cv := u.Rarities[own.Rarity]
+	cv.Rarity = own.Rarity
+	if own.MaxLevel != 0 {
+		cv.MaxLevel = own.MaxLevel
+	}
+	if own.Costs != nil {
+		cv.Costs = own.Costs
+	}
+	if own.Growth != nil {
+		growth := make(map[string]float64, len(cv.Growth)+len(own.Growth))
+		for s, g := range cv.Growth {
+			growth[s] = g
+		}
+		for s, g := range own.Growth {
+			growth[s] = g
+		}
+		cv.Growth = growth
+	}
+	return cv
+}
+
+// validate checks every troop and tower has a complete curve and that
+// upgrades.json only names units and rarities that exist.
+func (u Upgrades) validate(troops, towers []string) error {
+	if err := u.validateUnits(KindTroop, troops, u.Troops); err != nil {
+		return err
+	}
+	return u.validateUnits(KindTower, towers, u.Towers)
+}
+
+func (u Upgrades) validateUnits(kind string, names []string, curves map[string]Curve) error {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+		own, ok := curves[name]
+		if !ok {
+			return fmt.Errorf("upgrades.json: no curve for %s %q", kind, name)
+		}
+		if _, ok := u.Rarities[own.Rarity]; own.Rarity != "" && !ok {
+			return fmt.Errorf("upgrades.json: %s %q has unknown rarity %q", kind, name, own.Rarity)
+		}
+		if err := u.resolve(own).validate(); err != nil {
+			return fmt.Errorf("upgrades.json: %s %q: %w", kind, name, err)
+		}
+	}
+	for name := range curves {
+		if !known[name] {
+			return fmt.Errorf("upgrades.json: unknown %s %q", kind, name)
+		}
+	}
+	return nil
+}
+
+func (cv Curve) validate() error {
+	if cv.MaxLevel < 1 {
+		return fmt.Errorf("maxLevel must be at least 1")
+	}
+	if len(cv.Costs) != cv.MaxLevel-1 {
+		return fmt.Errorf("%d costs for max level %d, want %d", len(cv.Costs), cv.MaxLevel, cv.MaxLevel-1)
+	}
+	for i, cost := range cv.Costs {
+		if cost < 0 {
+			return fmt.Errorf("cost for level %d is negative", i+2)
+		}
+	}
+	for s, g := range cv.Growth {
+		if s != StatHP && s != StatATK && s != StatDEF {
+			return fmt.Errorf("unknown stat %q", s)
+		}
+		if g <= 0 {
+			return fmt.Errorf("growth for %s must be positive", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"time"
+
+	"clashroyale/internal/model"
+	"clashroyale/internal/spec"
+)
+
+// Stats are a player's lifetime match figures.
+type Stats struct {
+	Matches         int            `json:"matches"`
+	Wins            int            `json:"wins"`
+	Losses          int            `json:"losses"`
+	Draws           int            `json:"draws"`
+	Streak          int            `json:"streak"` // wins in a row, up to the last match
+	BestStreak      int            `json:"bestStreak"`
+	TowersDestroyed int            `json:"towersDestroyed"`
+	Damage          int            `json:"damage"`
+	Deploys         int            `json:"deploys"`
+	Troops          map[string]int `json:"troops,omitempty"` // deploys by troop
+}
+
+// Record adds a finished match.
+func (s *Stats) Record(m model.MatchSummary) {
+	s.Matches++
+	switch m.Outcome {
+	case spec.OutcomeWin:
+		s.Wins++
+		s.Streak++
+		s.BestStreak = max(s.BestStreak, s.Streak)
+	case spec.OutcomeLoss:
+		s.Losses++
+		s.Streak = 0
+	default:
+		s.Draws++
+		s.Streak = 0
+	}
+	s.TowersDestroyed += len(m.Destroyed)
+	s.Damage += m.Damage
+	for troop, n := range m.Deploys {
+		if s.Troops == nil {
+			s.Troops = make(map[string]int)
+		}
+		s.Troops[troop] += n
+		s.Deploys += n
+	}
+}
+
+// Favorite is the troop deployed most, alphabetically first on a tie, or
+// "" before any deploys.
+func (s Stats) Favorite() string {
+	fav := ""
+	for troop, n := range s.Troops {
+		if n > s.Troops[fav] || (n == s.Troops[fav] && troop < fav) {
+			fav = troop
+		}
+	}
+	return fav
+}
+
+// Value returns the named stat, see the spec.Stat constants.
+func (s Stats) Value(stat string) int {
+	switch stat {
+	case spec.StatMatches:
+		return s.Matches
+	case spec.StatWins:
+		return s.Wins
+	case spec.StatLosses:
+		return s.Losses
+	case spec.StatDraws:
+		return s.Draws
+	case spec.StatBestStreak:
+		return s.BestStreak
+	case spec.StatTowers:
+		return s.TowersDestroyed
+	case spec.StatDamage:
+		return s.Damage
+	case spec.StatDeploys:
+		return s.Deploys
+	}
+	return 0
+}
+
+// Season is a player's figures for one competitive season.
+type Season struct {
+	ID           string `json:"id,omitempty"` // the season they're for
+	Matches      int    `json:"matches,omitempty"`
+	PeakTrophies int    `json:"peakTrophies,omitempty"`
+	PeakRank     int    `json:"peakRank,omitempty"` // best leaderboard place after a match, 0 before any
+	Closed       string `json:"closed,omitempty"`   // the last season whose end has been applied to the account
+}
+
+// Record counts a match finished during season id that left the player
+// with trophies at leaderboard place rank. Figures from an earlier season
+// are dropped.
+func (s *Season) Record(id string, trophies, rank int) {
+	if s.ID != id {
+		*s = Season{ID: id, Closed: s.Closed}
+	}
+	s.Matches++
+	s.PeakTrophies = max(s.PeakTrophies, trophies)
+	if rank > 0 && (s.PeakRank == 0 || rank < s.PeakRank) {
+		s.PeakRank = rank
+	}
+}
+
+// Earned is an achievement a player holds.
+type Earned struct {
+	ID string    `json:"id"`
+	At time.Time `json:"at"`
+}
+
+// Award adds every achievement in cat that s has reached and earned
+// doesn't hold yet, and returns their IDs.
+func Award(cat *spec.Catalog, earned *[]Earned, s Stats, now time.Time) []string {
+	has := make(map[string]bool, len(*earned))
+	for _, e := range *earned {
+		has[e.ID] = true
+	}
+	var ids []string
+	for _, a := range cat.Achievements() {
+		if !has[a.ID] && s.Value(a.Stat) >= a.Threshold {
+			*earned = append(*earned, Earned{a.ID, now})
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,478 @@
+package tournament
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"clashroyale/internal/game"
+	"clashroyale/internal/lobby"
+
+	"github.com/google/uuid"
+)
+
+// Dir is where tournaments are saved, one JSON file each.
+var Dir = filepath.Join("data", "tournaments")
+
+// Lobby is the part of the lobby a tournament needs to put players in a
+// game together.
+type Lobby interface {
+	StartGame(mt lobby.Match) (string, error)
+	GetMatch(gameID string) (lobby.Match, bool)
+}
+
+// gameRef points from a lobby game back to the series it belongs to.
+type gameRef struct {
+	t *Tournament
+	s *Series
+}
+
+// Manager runs every tournament. Register HandleResult with game.OnFinish
+// so brackets advance as games end.
+type Manager struct {
+	dir         string
+	lobby       Lobby
+	tournaments map[string]*Tournament
+	games       map[string]gameRef // lobby game ID -> series
+	mu          sync.Mutex
+}
+
+// NewManager loads the tournaments saved in dir. Games that were being
+// played when the server stopped are picked up again if the lobby
+// restored them; otherwise their series start a fresh game on the next
+// tick.
+func NewManager(dir string, lm Lobby) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	m := &Manager{
+		dir:         dir,
+		lobby:       lm,
+		tournaments: make(map[string]*Tournament),
+		games:       make(map[string]gameRef),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var t Tournament
+		if err := json.Unmarshal(b, &t); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		if r := t.CurrentRound(); r != nil && t.Status == StatusRunning {
+			for _, s := range r.Series {
+				if _, ok := lm.GetMatch(s.Current); ok {
+					m.games[s.Current] = gameRef{&t, s}
+				} else {
+					s.Current = ""
+				}
+			}
+		}
+		m.tournaments[t.ID] = &t
+	}
+	return m, nil
+}
+
+// Create sets up a new tournament, open for registration.
+func (m *Manager) Create(cfg Config, createdBy string) (Tournament, error) {
+	now := time.Now()
+	if cfg.RegistrationOpens.IsZero() {
+		cfg.RegistrationOpens = now
+	}
+	if err := cfg.validate(); err != nil {
+		return Tournament{}, err
+	}
+
+	t := &Tournament{
+		ID:        uuid.NewString()[:8],
+		Config:    cfg,
+		Status:    StatusRegistering,
+		Players:   []string{},
+		Points:    make(map[string]int),
+		Bracket:   []*Round{},
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tournaments[t.ID] = t
+	return t.copy(), m.save(t)
+}
+
+// Register enters username into the tournament.
+func (m *Manager) Register(id, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !t.Open(time.Now()):
+		return ErrRegistrationClosed
+	case t.Registered(username):
+		return ErrAlreadyRegistered
+	case t.MaxPlayers > 0 && len(t.Players) >= t.MaxPlayers:
+		return ErrTournamentFull
+	}
+	t.Players = append(t.Players, username)
+	return m.save(t)
+}
+
+// Unregister withdraws username before the tournament starts.
+func (m *Manager) Unregister(id, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if t.Status != StatusRegistering {
+		return ErrAlreadyStarted
+	}
+	i := t.seed(username)
+	if i < 0 {
+		return ErrNotRegistered
+	}
+	t.Players = append(t.Players[:i], t.Players[i+1:]...)
+	return m.save(t)
+}
+
+// Start closes registration and pairs the first round.
+func (m *Manager) Start(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if t.Status != StatusRegistering {
+		return ErrAlreadyStarted
+	}
+	if len(t.Players) < 2 {
+		return ErrNotEnoughPlayers
+	}
+	m.start(t, time.Now())
+	return m.save(t)
+}
+
+// Cancel stops a tournament. Games already in progress play out but no
+// longer count.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if t.Status == StatusFinished || t.Status == StatusCancelled {
+		return nil
+	}
+	t.Status = StatusCancelled
+	t.FinishedAt = time.Now()
+	for gid, ref := range m.games {
+		if ref.t == t {
+			delete(m.games, gid)
+		}
+	}
+	return m.save(t)
+}
+
+// Get returns a copy of the tournament.
+func (m *Manager) Get(id string) (Tournament, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.get(id)
+	if err != nil {
+		return Tournament{}, err
+	}
+	return t.copy(), nil
+}
+
+// List returns copies of every tournament, newest first.
+func (m *Manager) List() []Tournament {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Tournament, 0, len(m.tournaments))
+	for _, t := range m.tournaments {
+		out = append(out, t.copy())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// HandleResult records a finished game against its series and, once the
+// round is over, pairs the next one. Games outside any tournament are
+// ignored.
+func (m *Manager) HandleResult(r game.Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ref, ok := m.games[r.GameID]
+	if !ok {
+		return
+	}
+	delete(m.games, r.GameID)
+	t, s := ref.t, ref.s
+	s.Current = ""
+	s.Games = append(s.Games, r.GameID)
+
+	// draws don't count towards the series
+	if w := r.Winners(); len(w) == 1 {
+		s.Wins[w[0]]++
+	}
+	if w := t.seriesWinner(s); w != "" {
+		m.decide(t, s, w)
+	}
+
+	if m.roundDone(t) {
+		m.nextRound(t, time.Now())
+	} else {
+		m.startGames(t)
+	}
+	if err := m.save(t); err != nil {
+		log.Printf("tournament %s: %v", t.ID, err)
+	}
+}
+
+// Run starts tournaments whose registration has closed and retries games
+// that couldn't start because a player was busy, every interval until
+// stop is closed.
+func (m *Manager) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			m.tick(now)
+		}
+	}
+}
+
+func (m *Manager) tick(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.tournaments {
+		switch t.Status {
+		case StatusRegistering:
+			if t.RegistrationCloses.IsZero() || now.Before(t.RegistrationCloses) {
+				continue
+			}
+			if len(t.Players) < 2 {
+				t.Status = StatusCancelled
+				t.FinishedAt = now
+			} else {
+				m.start(t, now)
+			}
+		case StatusRunning:
+			m.startGames(t)
+		default:
+			continue
+		}
+		if err := m.save(t); err != nil {
+			log.Printf("tournament %s: %v", t.ID, err)
+		}
+	}
+}
+
+// start closes registration and pairs round one. Caller must hold m.mu.
+func (m *Manager) start(t *Tournament, now time.Time) {
+	t.Status = StatusRunning
+	t.StartedAt = now
+	for _, p := range t.Players {
+		t.Points[p] = 0
+	}
+	m.nextRound(t, now)
+}
+
+// nextRound pairs the next round, or crowns the champion if there isn't
+// one. Caller must hold m.mu.
+func (m *Manager) nextRound(t *Tournament, now time.Time) {
+	var series []*Series
+	switch t.Format {
+	case SingleElimination:
+		players := t.Players
+		if r := t.CurrentRound(); r != nil {
+			players = nil
+			for _, s := range r.Series {
+				players = append(players, s.Winner)
+			}
+		}
+		if len(players) == 1 {
+			m.finish(t, players[0], now)
+			return
+		}
+		series = t.pairElimination(players)
+	case Swiss:
+		if len(t.Bracket) == t.totalRounds() {
+			m.finish(t, t.Standings()[0].Player, now)
+			return
+		}
+		series = t.pairSwiss()
+	}
+
+	t.Bracket = append(t.Bracket, &Round{Number: len(t.Bracket) + 1, Series: series})
+	for _, s := range series {
+		if s.Bye() {
+			t.Points[s.A]++
+		}
+	}
+	m.startGames(t)
+}
+
+// finish ends the tournament. Caller must hold m.mu.
+func (m *Manager) finish(t *Tournament, champion string, now time.Time) {
+	t.Status = StatusFinished
+	t.Champion = champion
+	t.FinishedAt = now
+}
+
+// decide settles a series. Caller must hold m.mu.
+func (m *Manager) decide(t *Tournament, s *Series, winner string) {
+	s.Winner = winner
+	t.Points[winner]++
+}
+
+// roundDone reports whether every series in the current round is
+// settled. Caller must hold m.mu.
+func (m *Manager) roundDone(t *Tournament) bool {
+	for _, s := range t.CurrentRound().Series {
+		if s.Winner == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// startGames puts every unsettled series of the current round that isn't
+// already playing into a game. Series whose players are busy elsewhere
+// are left for the next tick. A player barred from matches by a ban or
+// suspension forfeits the series, so the bracket doesn't wait on them.
+// Caller must hold m.mu.
+func (m *Manager) startGames(t *Tournament) {
+	if t.Status != StatusRunning {
+		return
+	}
+	walkover := false
+	for _, s := range t.CurrentRound().Series {
+		if s.Winner != "" || s.Current != "" {
+			continue
+		}
+		// swap sides every game
+		players := []string{s.A, s.B}
+		if len(s.Games)%2 == 1 {
+			players[0], players[1] = s.B, s.A
+		}
+		id, err := m.lobby.StartGame(lobby.Match{Players: players, Sides: []int{0, 1}, Options: t.options()})
+		var barred *lobby.BarredError
+		if errors.As(err, &barred) {
+			m.decide(t, s, s.opponent(barred.Player))
+			walkover = true
+			continue
+		}
+		if errors.Is(err, lobby.ErrAlreadyInGame) || errors.Is(err, lobby.ErrClosed) {
+			continue
+		}
+		if err != nil {
+			log.Printf("tournament %s: starting %s vs %s: %v", t.ID, s.A, s.B, err)
+			continue
+		}
+		s.Current = id
+		m.games[id] = gameRef{t, s}
+	}
+	if walkover && m.roundDone(t) {
+		m.nextRound(t, time.Now())
+	}
+}
+
+// get looks a tournament up. Caller must hold m.mu.
+func (m *Manager) get(id string) (*Tournament, error) {
+	t, ok := m.tournaments[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTournamentNotFound, id)
+	}
+	return t, nil
+}
+
+// save writes the tournament to disk. Caller must hold m.mu.
+func (m *Manager) save(t *Tournament) error {
+	f, err := os.Create(filepath.Join(m.dir, t.ID+".json"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// seriesWinner returns who has won the series, if anyone has. A series
+// that keeps drawing goes to the higher seed after twice its length.
+func (t *Tournament) seriesWinner(s *Series) string {
+	need := t.BestOf/2 + 1
+	for _, p := range []string{s.A, s.B} {
+		if s.Wins[p] >= need {
+			return p
+		}
+	}
+	if len(s.Games) < 2*t.BestOf {
+		return ""
+	}
+	switch a, b := s.Wins[s.A], s.Wins[s.B]; {
+	case a > b:
+		return s.A
+	case b > a:
+		return s.B
+	case t.seed(s.A) < t.seed(s.B):
+		return s.A
+	default:
+		return s.B
+	}
+}
+
+// copy returns a deep copy of t, safe to hand out.
+func (t *Tournament) copy() Tournament {
+	c := *t
+	c.Players = append([]string{}, t.Players...)
+	c.Points = make(map[string]int, len(t.Points))
+	for k, v := range t.Points {
+		c.Points[k] = v
+	}
+	c.Bracket = make([]*Round, len(t.Bracket))
+	for i, r := range t.Bracket {
+		rc := &Round{Number: r.Number, Series: make([]*Series, len(r.Series))}
+		for j, s := range r.Series {
+			sc := *s
+			sc.Games = append([]string{}, s.Games...)
+			sc.Wins = make(map[string]int, len(s.Wins))
+			for k, v := range s.Wins {
+				sc.Wins[k] = v
+			}
+			rc.Series[j] = &sc
+		}
+		c.Bracket[i] = rc
+	}
+	return c
+}
@@ -0,0 +1,147 @@
+package tournament
+
+// Pairing for each format. Every function here returns the series for
+// the next round; byes come first and are already decided.
+
+// pairElimination pairs the players still in a single elimination
+// tournament. Round one matches top seeds against bottom seeds, laid out
+// so the top two seeds can only meet in the final; after that, winners of
+// neighbouring series meet so the bracket reads left to right. With an
+// odd count, the best seed without a bye yet sits out.
+func (t *Tournament) pairElimination(players []string) []*Series {
+	var out []*Series
+	if len(players)%2 == 1 {
+		bye := t.pickBye(players, false)
+		out = append(out, byeSeries(players[bye]))
+		players = append(append([]string(nil), players[:bye]...), players[bye+1:]...)
+	}
+
+	if len(t.Bracket) == 0 {
+		for _, i := range bracketOrder(len(players) / 2) {
+			out = append(out, newSeries(players[i], players[len(players)-1-i]))
+		}
+		return out
+	}
+	for i := 0; i+1 < len(players); i += 2 {
+		out = append(out, newSeries(players[i], players[i+1]))
+	}
+	return out
+}
+
+// pairSwiss pairs everyone by current standing, avoiding rematches where
+// possible. With an odd count, the lowest ranked player without a bye
+// yet sits out and scores the point.
+func (t *Tournament) pairSwiss() []*Series {
+	var players []string
+	for _, st := range t.Standings() {
+		players = append(players, st.Player)
+	}
+
+	var out []*Series
+	if len(players)%2 == 1 {
+		bye := t.pickBye(players, true)
+		out = append(out, byeSeries(players[bye]))
+		players = append(append([]string(nil), players[:bye]...), players[bye+1:]...)
+	}
+
+	met := t.opponents()
+	paired := make(map[string]bool)
+	for i, a := range players {
+		if paired[a] {
+			continue
+		}
+		// first unpaired player below a they haven't met, else the first unpaired
+		b := ""
+		for _, c := range players[i+1:] {
+			if paired[c] {
+				continue
+			}
+			if b == "" {
+				b = c
+			}
+			if !met[a][c] {
+				b = c
+				break
+			}
+		}
+		paired[a], paired[b] = true, true
+		out = append(out, newSeries(a, b))
+	}
+	return out
+}
+
+// pickBye returns the index in players of who should get the bye: the
+// first (or, if fromBottom, last) player who hasn't had one yet.
+func (t *Tournament) pickBye(players []string, fromBottom bool) int {
+	had := make(map[string]bool)
+	for _, r := range t.Bracket {
+		for _, s := range r.Series {
+			if s.Bye() {
+				had[s.A] = true
+			}
+		}
+	}
+	for n := range players {
+		i := n
+		if fromBottom {
+			i = len(players) - 1 - n
+		}
+		if !had[players[i]] {
+			return i
+		}
+	}
+	if fromBottom {
+		return len(players) - 1
+	}
+	return 0
+}
+
+// opponents maps each player to everyone they've played so far.
+func (t *Tournament) opponents() map[string]map[string]bool {
+	met := make(map[string]map[string]bool)
+	for _, p := range t.Players {
+		met[p] = make(map[string]bool)
+	}
+	for _, r := range t.Bracket {
+		for _, s := range r.Series {
+			if !s.Bye() {
+				met[s.A][s.B] = true
+				met[s.B][s.A] = true
+			}
+		}
+	}
+	return met
+}
+
+// bracketOrder lays out n series, numbered by their better seed, so that
+// each half of the bracket holds one of the two best seeds, each quarter
+// one of the four best, and so on: 0 3 1 2 for four series.
+func bracketOrder(n int) []int {
+	order := []int{0}
+	for len(order) < n {
+		size := 2 * len(order)
+		next := make([]int, 0, size)
+		for _, i := range order {
+			next = append(next, i, size-1-i)
+		}
+		order = next
+	}
+	// without a power of two, the missing series are the weakest seeds'
+	out := order[:0]
+	for _, i := range order {
+		if i < n {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func newSeries(a, b string) *Series {
+	return &Series{A: a, B: b, Wins: make(map[string]int), Games: []string{}}
+}
+
+func byeSeries(a string) *Series {
+	s := newSeries(a, "")
+	s.Winner = a
+	return s
+}
@@ -0,0 +1,221 @@
+package tournament
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"clashroyale/internal/lobby"
+)
+
+// Format is how a tournament pairs its players.
+type Format string
+
+const (
+	// SingleElimination: lose a series and you're out.
+	SingleElimination Format = "single_elimination"
+	// Swiss: everyone plays a fixed number of rounds against players with
+	// the same score; most series wins takes it.
+	Swiss Format = "swiss"
+)
+
+// Status is where a tournament is in its lifecycle.
+type Status string
+
+const (
+	StatusRegistering Status = "registering"
+	StatusRunning     Status = "running"
+	StatusFinished    Status = "finished"
+	StatusCancelled   Status = "cancelled"
+)
+
+var (
+	ErrTournamentNotFound = errors.New("tournament not found")
+	ErrRegistrationClosed = errors.New("tournament registration is closed")
+	ErrAlreadyRegistered  = errors.New("already registered for this tournament")
+	ErrNotRegistered      = errors.New("not registered for this tournament")
+	ErrTournamentFull     = errors.New("tournament is full")
+	ErrNotEnoughPlayers   = errors.New("a tournament needs at least two players")
+	ErrAlreadyStarted     = errors.New("tournament has already started")
+	ErrInvalidConfig      = errors.New("invalid tournament settings")
+)
+
+// Config is what an organizer sets when creating a tournament.
+type Config struct {
+	Name       string `json:"name"`
+	Format     Format `json:"format"`
+	BestOf     int    `json:"bestOf"`     // games per series, odd; default 1
+	Rounds     int    `json:"rounds"`     // Swiss only; 0 = enough rounds to separate a winner
+	MaxPlayers int    `json:"maxPlayers"` // 0 = no limit
+
+	// Registration window. A zero open time means now; a zero close time
+	// means the tournament waits for an organizer to start it, otherwise
+	// it starts by itself when registration closes.
+	RegistrationOpens  time.Time `json:"registrationOpens"`
+	RegistrationCloses time.Time `json:"registrationCloses"`
+
+	// Match settings for every game, as for private rooms
+	Minutes int    `json:"minutes"` // default lobby.DefaultDuration
+	Mode    string `json:"mode"`    // default lobby.ModeClassic
+}
+
+// validate fills in defaults and checks the settings make sense.
+func (c *Config) validate() error {
+	if c.Format == "" {
+		c.Format = SingleElimination
+	}
+	if c.BestOf == 0 {
+		c.BestOf = 1
+	}
+	if c.Name == "" || c.BestOf < 0 || c.BestOf%2 == 0 || c.Rounds < 0 || c.MaxPlayers < 0 {
+		return ErrInvalidConfig
+	}
+	if c.Format != SingleElimination && c.Format != Swiss {
+		return ErrInvalidConfig
+	}
+	if c.MaxPlayers == 1 {
+		return ErrInvalidConfig
+	}
+	if !c.RegistrationCloses.IsZero() && c.RegistrationCloses.Before(c.RegistrationOpens) {
+		return ErrInvalidConfig
+	}
+	if c.Mode == "" {
+		c.Mode = lobby.ModeClassic
+	}
+	if c.Minutes == 0 {
+		c.Minutes = int(lobby.DefaultDuration / time.Minute)
+	}
+	if d := time.Duration(c.Minutes) * time.Minute; d < lobby.MinDuration || d > lobby.MaxDuration {
+		return ErrInvalidConfig
+	}
+	if c.Mode != lobby.ModeClassic && c.Mode != lobby.ModeDoubleMana {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+// options are the lobby options each game is played with.
+func (c *Config) options() lobby.Options {
+	return lobby.Options{Duration: time.Duration(c.Minutes) * time.Minute, Mode: c.Mode}
+}
+
+// Tournament is one event, its entrants and its bracket. It is what the
+// bracket endpoint serves and what gets saved to disk.
+type Tournament struct {
+	ID string `json:"id"`
+	Config
+	Status    Status         `json:"status"`
+	Players   []string       `json:"players"` // in registration order, which is also the seeding
+	Points    map[string]int `json:"points"`  // series won, byes included
+	Bracket   []*Round       `json:"bracket"`
+	Champion  string         `json:"champion,omitempty"`
+	CreatedBy string         `json:"createdBy"`
+
+	CreatedAt  time.Time `json:"createdAt"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// Round is one set of series played in parallel.
+type Round struct {
+	Number int       `json:"number"`
+	Series []*Series `json:"series"`
+}
+
+// Series is a best-of-N between two players. B is empty when A has a bye.
+type Series struct {
+	A       string         `json:"a"`
+	B       string         `json:"b,omitempty"`
+	Wins    map[string]int `json:"wins"`
+	Games   []string       `json:"games"`             // every game played, in order
+	Current string         `json:"current,omitempty"` // game being played now
+	Winner  string         `json:"winner,omitempty"`
+}
+
+// Bye reports whether the series is a bye.
+func (s *Series) Bye() bool {
+	return s.B == ""
+}
+
+// Has reports whether username plays in the series.
+func (s *Series) Has(username string) bool {
+	return s.A == username || s.B == username
+}
+
+// opponent returns who username faces in the series.
+func (s *Series) opponent(username string) string {
+	if s.A == username {
+		return s.B
+	}
+	return s.A
+}
+
+// Open reports whether registration is open at now.
+func (t *Tournament) Open(now time.Time) bool {
+	return t.Status == StatusRegistering &&
+		!now.Before(t.RegistrationOpens) &&
+		(t.RegistrationCloses.IsZero() || now.Before(t.RegistrationCloses))
+}
+
+// Registered reports whether username has entered.
+func (t *Tournament) Registered(username string) bool {
+	return t.seed(username) >= 0
+}
+
+// CurrentRound is the round being played, or nil before the start.
+func (t *Tournament) CurrentRound() *Round {
+	if len(t.Bracket) == 0 {
+		return nil
+	}
+	return t.Bracket[len(t.Bracket)-1]
+}
+
+// SeriesFor returns username's series in the current round, if any.
+func (t *Tournament) SeriesFor(username string) *Series {
+	if r := t.CurrentRound(); r != nil {
+		for _, s := range r.Series {
+			if s.Has(username) {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// Standing is one line of the standings table.
+type Standing struct {
+	Player string `json:"player"`
+	Points int    `json:"points"`
+}
+
+// Standings lists players by points, ties broken by seed.
+func (t *Tournament) Standings() []Standing {
+	out := make([]Standing, len(t.Players))
+	for i, p := range t.Players {
+		out[i] = Standing{p, t.Points[p]}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Points > out[j].Points })
+	return out
+}
+
+// seed is username's position in the registration order, or -1.
+func (t *Tournament) seed(username string) int {
+	for i, p := range t.Players {
+		if p == username {
+			return i
+		}
+	}
+	return -1
+}
+
+// totalRounds is how many rounds a Swiss tournament plays.
+func (t *Tournament) totalRounds() int {
+	if t.Rounds > 0 {
+		return t.Rounds
+	}
+	n := 0
+	for 1<<n < len(t.Players) {
+		n++
+	}
+	return n
+}
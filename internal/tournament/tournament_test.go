@@ -0,0 +1,269 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"clashroyale/internal/game"
+	"clashroyale/internal/lobby"
+)
+
+// fakeLobby starts every game it's asked to, unless a player is barred,
+// and keeps it running until the test plays it out.
+type fakeLobby struct {
+	games   map[string]lobby.Match
+	running []string // game IDs, oldest first
+	barred  map[string]bool
+}
+
+func (l *fakeLobby) StartGame(mt lobby.Match) (string, error) {
+	for _, p := range mt.Players {
+		if l.barred[p] {
+			return "", &lobby.BarredError{Player: p, Err: errors.New("suspended")}
+		}
+	}
+	id := fmt.Sprintf("g%d", len(l.games)+1)
+	l.games[id] = mt
+	l.running = append(l.running, id)
+	return id, nil
+}
+
+func (l *fakeLobby) GetMatch(id string) (lobby.Match, bool) {
+	mt, ok := l.games[id]
+	return mt, ok
+}
+
+// start runs a tournament of players p1..pn, seeded in that order.
+// Players in barred are turned away from every game.
+func start(t *testing.T, cfg Config, n int, barred ...string) (*Manager, *fakeLobby, string) {
+	t.Helper()
+	lb := &fakeLobby{games: make(map[string]lobby.Match), barred: make(map[string]bool)}
+	for _, p := range barred {
+		lb.barred[p] = true
+	}
+	m, err := NewManager(t.TempDir(), lb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Name = "test"
+	tr, err := m.Create(cfg, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= n; i++ {
+		if err := m.Register(tr.ID, fmt.Sprintf("p%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Start(tr.ID); err != nil {
+		t.Fatal(err)
+	}
+	return m, lb, tr.ID
+}
+
+// playOut finishes the lobby's games, oldest first, until none are left.
+// pick chooses each game's winner from its players.
+func playOut(t *testing.T, m *Manager, lb *fakeLobby, pick func(players []string) string) {
+	t.Helper()
+	for played := 0; len(lb.running) > 0; played++ {
+		if played > 100 {
+			t.Fatal("tournament still running after 100 games")
+		}
+		id := lb.running[0]
+		lb.running = lb.running[1:]
+
+		mt := lb.games[id]
+		r := game.Result{GameID: id, Players: mt.Players, Sides: mt.Sides, WinnerSide: -1}
+		if w := pick(mt.Players); w != "" {
+			r.WinnerSide = mt.Sides[slices.Index(mt.Players, w)]
+		}
+		m.HandleResult(r)
+	}
+}
+
+// higherSeed wins every game.
+func higherSeed(players []string) string {
+	return slices.MinFunc(players, func(a, b string) int {
+		var x, y int
+		fmt.Sscanf(a, "p%d", &x)
+		fmt.Sscanf(b, "p%d", &y)
+		return x - y
+	})
+}
+
+// pairings lists each round's series as "a-b", or "a" for a bye.
+func pairings(tr Tournament) [][]string {
+	var out [][]string
+	for _, r := range tr.Bracket {
+		var round []string
+		for _, s := range r.Series {
+			if s.Bye() {
+				round = append(round, s.A)
+			} else {
+				round = append(round, s.A+"-"+s.B)
+			}
+		}
+		out = append(out, round)
+	}
+	return out
+}
+
+func TestBracket(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		players      int
+		want         [][]string
+		wantChampion string
+		wantPoints   map[string]int
+	}{
+		{
+			name:    "single elimination",
+			players: 8,
+			want: [][]string{
+				{"p1-p8", "p4-p5", "p2-p7", "p3-p6"},
+				{"p1-p4", "p2-p3"},
+				{"p1-p2"},
+			},
+			wantChampion: "p1",
+			wantPoints:   map[string]int{"p1": 3, "p2": 2, "p3": 1, "p4": 1},
+		},
+		{
+			name:    "single elimination, six players",
+			players: 6,
+			want: [][]string{
+				{"p1-p6", "p2-p5", "p3-p4"},
+				{"p1", "p2-p3"},
+				{"p1-p2"},
+			},
+			wantChampion: "p1",
+			wantPoints:   map[string]int{"p1": 3, "p2": 2, "p3": 1},
+		},
+		{
+			name:    "single elimination with byes",
+			players: 5,
+			want: [][]string{
+				{"p1", "p2-p5", "p3-p4"},
+				{"p2", "p1-p3"},
+				{"p2-p1"},
+			},
+			wantChampion: "p1",
+			wantPoints:   map[string]int{"p1": 3, "p2": 2, "p3": 1},
+		},
+		{
+			name:    "swiss",
+			cfg:     Config{Format: Swiss},
+			players: 4,
+			want: [][]string{
+				{"p1-p2", "p3-p4"},
+				{"p1-p3", "p2-p4"},
+			},
+			wantChampion: "p1",
+			wantPoints:   map[string]int{"p1": 2, "p2": 1, "p3": 1},
+		},
+		{
+			name:    "swiss with a bye",
+			cfg:     Config{Format: Swiss},
+			players: 3,
+			want: [][]string{
+				{"p3", "p1-p2"},
+				{"p2", "p1-p3"},
+			},
+			wantChampion: "p1",
+			wantPoints:   map[string]int{"p1": 2, "p2": 1, "p3": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, lb, id := start(t, tt.cfg, tt.players)
+			playOut(t, m, lb, higherSeed)
+
+			tr, err := m.Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pairings(tr); !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("pairings = %v, want %v", got, tt.want)
+			}
+			if tr.Status != StatusFinished || tr.Champion != tt.wantChampion {
+				t.Errorf("status %s, champion %q; want finished, %q", tr.Status, tr.Champion, tt.wantChampion)
+			}
+			for p, want := range tt.wantPoints {
+				if tr.Points[p] != want {
+					t.Errorf("%s has %d points, want %d", p, tr.Points[p], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBestOf(t *testing.T) {
+	m, lb, id := start(t, Config{BestOf: 3}, 2)
+
+	// p2 takes the first game, the second is drawn, then p1 wins two
+	results := []string{"p2", "", "p1", "p1"}
+	var sides [][]string
+	playOut(t, m, lb, func(players []string) string {
+		sides = append(sides, players)
+		w := results[0]
+		results = results[1:]
+		return w
+	})
+
+	tr, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tr.Bracket[0].Series[0]
+	if len(s.Games) != 4 || s.Wins["p1"] != 2 || s.Wins["p2"] != 1 {
+		t.Errorf("series played %d games, won %v; want 4 games, p1 2-1", len(s.Games), s.Wins)
+	}
+	if tr.Champion != "p1" {
+		t.Errorf("champion = %q, want p1", tr.Champion)
+	}
+	for i, players := range sides {
+		if first := []string{"p1", "p2"}[i%2]; players[0] != first {
+			t.Errorf("game %d played as %v, want %s on side 0", i+1, players, first)
+		}
+	}
+}
+
+// TestWalkover checks a barred player forfeits rather than holding the
+// bracket up.
+func TestWalkover(t *testing.T) {
+	// p1 is barred, and would otherwise win everything
+	m, lb, id := start(t, Config{}, 4, "p1")
+	playOut(t, m, lb, higherSeed)
+
+	tr, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"p1-p4", "p2-p3"}, {"p4-p2"}}
+	if got := pairings(tr); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("pairings = %v, want %v", got, want)
+	}
+	if s := tr.Bracket[0].Series[0]; s.Winner != "p4" || len(s.Games) != 0 {
+		t.Errorf("barred player's series = %+v, want a walkover for p4", s)
+	}
+	if tr.Status != StatusFinished || tr.Champion != "p2" {
+		t.Errorf("status %s, champion %q; want finished, p2", tr.Status, tr.Champion)
+	}
+}
+
+// TestResultOutsideTournament checks games the manager didn't start
+// leave the bracket alone.
+func TestResultOutsideTournament(t *testing.T) {
+	m, _, id := start(t, Config{}, 2)
+	m.HandleResult(game.Result{GameID: "other", Players: []string{"p1", "p2"}, Sides: []int{0, 1}, WinnerSide: 1})
+
+	tr, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tr.Bracket[0].Series[0]; s.Winner != "" || len(s.Games) != 0 || s.Current == "" {
+		t.Errorf("series = %+v, want its own game still running", s)
+	}
+}
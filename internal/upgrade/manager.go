@@ -1,63 +1,179 @@
 package upgrade
 
 import (
+	"clashroyale/internal/collection"
 	"clashroyale/internal/model"
-	"math"
+	"clashroyale/internal/spec"
+	"clashroyale/internal/wallet"
+	"errors"
 )
 
-// CalculateUpgradeCost calculates the EXP cost for upgrading using
-// the unit's own baseCost, increasing by 10% per level (rounded down).
-func CalculateUpgradeCost(baseCost, currentLevel int) int {
-	return int(float64(baseCost) * math.Pow(1.1, float64(currentLevel)))
+var (
+	// ErrNotEnoughGold is reported when a player can't afford an upgrade.
+	ErrNotEnoughGold = errors.New("not enough gold to upgrade")
+	// ErrMaxLevel is reported when a unit is already at its curve's top level.
+	ErrMaxLevel = errors.New("already at max level")
+	// ErrUnknownUnit is reported for a troop or tower the catalog has no curve for.
+	ErrUnknownUnit = errors.New("unknown troop or tower")
+)
+
+// Stats are a unit's fighting stats at one level.
+type Stats struct {
+	HP  int `json:"hp"`
+	ATK int `json:"atk"`
+	DEF int `json:"def"`
 }
 
-// CalculateUpgradeStats calculates the new stats after an upgrade
-// using the base stat and current level
-func CalculateUpgradeStats(baseStat, level int) int {
-	val := float64(baseStat) * math.Pow(1.1, float64(level))
-	return int(math.Ceil(val))
+// Preview is a unit's level and stats and, unless it's maxed out, what
+// the next level costs and gives.
+type Preview struct {
+	Kind       string `json:"kind"` // spec.KindTroop or spec.KindTower
+	Name       string `json:"name"`
+	Rarity     string `json:"rarity,omitempty"`
+	Level      int    `json:"level"`
+	MaxLevel   int    `json:"maxLevel"`
+	Stats      Stats  `json:"stats"`
+	Next       *Stats `json:"next,omitempty"` // nil at MaxLevel
+	Cost       int    `json:"cost"`           // gold for the next level, 0 at MaxLevel
+	Affordable bool   `json:"affordable"`
 }
 
-// CanUpgradeTroop checks if a player can upgrade a specific troop
-// using that troop's own Exp value as the base cost.
-func CanUpgradeTroop(player *model.Player, troop *model.Troop) (bool, int) {
-	lvl := player.TroopLevels[troop.Name]
-	cost := CalculateUpgradeCost(troop.Exp, lvl)
-	return player.Exp >= cost, cost
+// statsAt grows base to level along cv.
+func statsAt(cv spec.Curve, base Stats, level int) Stats {
+	return Stats{
+		HP:  cv.Stat(spec.StatHP, base.HP, level),
+		ATK: cv.Stat(spec.StatATK, base.ATK, level),
+		DEF: cv.Stat(spec.StatDEF, base.DEF, level),
+	}
 }
 
-// CanUpgradeTower checks if a player can upgrade a specific tower
-// using that tower's own Exp value as the base cost.
-func CanUpgradeTower(player *model.Player, tower *model.Tower) (bool, int) {
-	lvl := player.TowerLevels[tower.Name]
-	cost := CalculateUpgradeCost(tower.Exp, lvl)
-	return player.Exp >= cost, cost
+// LevelTroop scales t, a copy of a troop's base spec, to level. Levels
+// past the troop's max count as the max.
+func LevelTroop(cat *spec.Catalog, t *model.Troop, level int) {
+	cv, ok := cat.Curve(spec.KindTroop, t.Name)
+	if !ok {
+		return
+	}
+	s := statsAt(cv, Stats{t.HP, t.ATK, t.DEF}, level)
+	t.HP, t.ATK, t.DEF = s.HP, s.ATK, s.DEF
 }
 
-// UpgradeTroop upgrades a troop (deducts EXP, bumps its level, recalculates stats).
-func UpgradeTroop(player *model.Player, troop *model.Troop) (bool, error) {
-	can, cost := CanUpgradeTroop(player, troop)
-	if !can {
-		return false, nil
+// LevelTower scales t, a copy of a tower's base spec, to level.
+func LevelTower(cat *spec.Catalog, t *model.Tower, level int) {
+	cv, ok := cat.Curve(spec.KindTower, t.Name)
+	if !ok {
+		return
 	}
+	s := statsAt(cv, Stats{t.HP, t.ATK, t.DEF}, level)
+	t.HP, t.ATK, t.DEF = s.HP, s.ATK, s.DEF
+}
 
-	player.Exp -= cost
-	newLevel := player.TroopLevels[troop.Name] + 1
-	player.TroopLevels[troop.Name] = newLevel
+// preview works out a Preview for a unit with base stats at level, for
+// a player holding gold.
+func preview(cat *spec.Catalog, kind, name string, base Stats, level, gold int) (Preview, error) {
+	cv, ok := cat.Curve(kind, name)
+	if !ok {
+		return Preview{}, ErrUnknownUnit
+	}
+	level = cv.Level(level)
+	p := Preview{
+		Kind:     kind,
+		Name:     name,
+		Rarity:   cv.Rarity,
+		Level:    level,
+		MaxLevel: cv.MaxLevel,
+		Stats:    statsAt(cv, base, level),
+	}
+	if cost, ok := cv.Cost(level); ok {
+		next := statsAt(cv, base, level+1)
+		p.Next = &next
+		p.Cost = cost
+		p.Affordable = gold >= cost
+	}
+	return p, nil
+}
 
-	return true, nil
+// PreviewTroop shows player's level of the named troop and what the next
+// level would cost and give.
+func PreviewTroop(cat *spec.Catalog, player *model.Player, name string) (Preview, error) {
+	t, ok := cat.Troop(name)
+	if !ok {
+		return Preview{}, ErrUnknownUnit
+	}
+	return preview(cat, spec.KindTroop, name, Stats{t.HP, t.ATK, t.DEF}, player.TroopLevels[name], player.Wallet[wallet.Gold])
 }
 
-// UpgradeTower upgrades a tower similarly.
-func UpgradeTower(player *model.Player, tower *model.Tower) (bool, error) {
-	can, cost := CanUpgradeTower(player, tower)
-	if !can {
-		return false, nil
+// PreviewTower does the same for a tower.
+func PreviewTower(cat *spec.Catalog, player *model.Player, name string) (Preview, error) {
+	t, ok := cat.Tower(name)
+	if !ok {
+		return Preview{}, ErrUnknownUnit
 	}
+	return preview(cat, spec.KindTower, name, Stats{t.HP, t.ATK, t.DEF}, player.TowerLevels[name], player.Wallet[wallet.Gold])
+}
 
-	player.Exp -= cost
-	newLevel := player.TowerLevels[tower.Name] + 1
-	player.TowerLevels[tower.Name] = newLevel
+// Previews lists PreviewTroop for every troop the player owns and
+// PreviewTower for every tower, in catalog order.
+func Previews(cat *spec.Catalog, player *model.Player) (troops, towers []Preview, err error) {
+	for _, t := range cat.Troops() {
+		if !player.Owns(t.Name) {
+			continue
+		}
+		p, err := PreviewTroop(cat, player, t.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		troops = append(troops, p)
+	}
+	for _, t := range cat.Towers() {
+		p, err := PreviewTower(cat, player, t.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		towers = append(towers, p)
+	}
+	return troops, towers, nil
+}
 
-	return true, nil
+// UpgradeTroop upgrades a troop (deducts gold, bumps its level) and
+// returns what it cost. The caller persists the change.
+func UpgradeTroop(cat *spec.Catalog, player *model.Player, name string) (int, error) {
+	p, err := PreviewTroop(cat, player, name)
+	if err != nil {
+		return 0, err
+	}
+	if !player.Owns(name) {
+		return 0, collection.ErrLocked
+	}
+	if err := p.check(); err != nil {
+		return 0, err
+	}
+	player.Wallet[wallet.Gold] -= p.Cost
+	player.TroopLevels[name] = p.Level + 1
+	return p.Cost, nil
+}
+
+// UpgradeTower upgrades a tower similarly.
+func UpgradeTower(cat *spec.Catalog, player *model.Player, name string) (int, error) {
+	p, err := PreviewTower(cat, player, name)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.check(); err != nil {
+		return 0, err
+	}
+	player.Wallet[wallet.Gold] -= p.Cost
+	player.TowerLevels[name] = p.Level + 1
+	return p.Cost, nil
+}
+
+// check reports why the previewed upgrade can't go ahead, if it can't.
+func (p Preview) check() error {
+	if p.Next == nil {
+		return ErrMaxLevel
+	}
+	if !p.Affordable {
+		return ErrNotEnoughGold
+	}
+	return nil
 }
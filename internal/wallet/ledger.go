@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Dir holds each player's ledger, one JSON entry per line in
+// <username>.jsonl. Ledgers are only ever appended to.
+var Dir = filepath.Join("data", "ledger")
+
+// Reasons recorded in the ledger.
+const (
+	ReasonMatch     = "match"     // Ref is the game ID
+	ReasonUpgrade   = "upgrade"   // Ref is the troop or tower
+	ReasonAdmin     = "admin"     // Ref is the admin's username
+	ReasonChest     = "chest"     // Ref is the chest type
+	ReasonQuest     = "quest"     // Ref is the quest ID
+	ReasonSeason    = "season"    // end-of-season reward and trophy reset, Ref is the season ID
+	ReasonPass      = "pass"      // battle pass tier, Ref is "<season>/<track>/<tier>"
+	ReasonMigration = "migration" // balances carried over from the single EXP counter
+)
+
+// Entry is one credit (positive Amount) or debit (negative) to a wallet.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Currency Currency  `json:"currency"`
+	Amount   int       `json:"amount"`
+	Balance  int       `json:"balance"` // after this entry
+	Reason   string    `json:"reason"`
+	Ref      string    `json:"ref,omitempty"`
+}
+
+var mu sync.Mutex
+
+// Record appends an entry per non-zero change in applied to username's
+// ledger. balances are the wallet's holdings after the change.
+func Record(username, reason, ref string, applied, balances Balances) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(Dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(Dir, username+".jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	now := time.Now()
+	enc := json.NewEncoder(f)
+	for _, c := range Currencies {
+		if applied[c] == 0 {
+			continue
+		}
+		if err := enc.Encode(Entry{now, c, applied[c], balances[c], reason, ref}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// History returns up to limit of username's ledger entries, newest first.
+func History(username string, limit int) ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, err := os.Open(filepath.Join(Dir, username+".jsonl"))
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var all []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		all = append(all, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]Entry, 0, min(limit, len(all)))
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, all[i])
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Currency is one of the balances a player holds.
+type Currency string
+
+const (
+	Gold     Currency = "gold"     // earned in matches, spent on upgrades
+	Exp      Currency = "exp"      // progression, only ever goes up in play
+	Trophies Currency = "trophies" // ranking, won and lost in matches
+	PassXP   Currency = "pass_xp"  // battle pass progress, reset when a season ends
+)
+
+// Currencies lists every currency in display order.
+var Currencies = []Currency{Gold, Exp, Trophies, PassXP}
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUnknownCurrency   = errors.New("unknown currency")
+)
+
+// Valid reports whether c is a known currency.
+func (c Currency) Valid() bool {
+	for _, k := range Currencies {
+		if k == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Balances is an amount per currency. The same type carries a wallet's
+// holdings and a set of changes to apply to one.
+type Balances map[Currency]int
+
+// Validate checks every currency in b is known.
+func (b Balances) Validate() error {
+	for c := range b {
+		if !c.Valid() {
+			return fmt.Errorf("%w: %q", ErrUnknownCurrency, c)
+		}
+	}
+	return nil
+}
+
+// Apply adds changes to b and returns the amounts actually moved. It
+// changes nothing and returns ErrInsufficientFunds if gold or EXP would
+// go negative. Trophy losses are capped at what the player holds
+// instead: you can't drop below zero trophies.
+func (b Balances) Apply(changes Balances) (Balances, error) {
+	if err := changes.Validate(); err != nil {
+		return nil, err
+	}
+	applied := make(Balances, len(changes))
+	for c, amt := range changes {
+		if amt == 0 {
+			continue
+		}
+		if b[c]+amt < 0 {
+			if c != Trophies {
+				return nil, fmt.Errorf("%w: %d %s needed, %d held", ErrInsufficientFunds, -amt, c, b[c])
+			}
+			amt = -b[c]
+		}
+		if amt != 0 {
+			applied[c] = amt
+		}
+	}
+	for c, amt := range applied {
+		b[c] += amt
+	}
+	return applied, nil
+}
+
+// Sub returns a - b per currency, e.g. to turn a target balance into the
+// change that reaches it.
+func Sub(a, b Balances) Balances {
+	out := make(Balances)
+	for c, amt := range a {
+		out[c] += amt
+	}
+	for c, amt := range b {
+		out[c] -= amt
+	}
+	return out
+}